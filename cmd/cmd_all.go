@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"dirstat/module"
+	"github.com/aegoroff/dirstat/module"
 	"github.com/spf13/cobra"
 )
 
@@ -9,25 +9,33 @@ func newAll(c conf) *cobra.Command {
 	opt := options{}
 
 	var cmd = &cobra.Command{
-		Use:     "a",
+		Use:     "a [path...]",
 		Aliases: []string{"all"},
 		Short:   "Show all information about folder/volume",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := module.NewContext(top)
-			foldersmod := module.NewFoldersModule(ctx, false)
+			ctx := module.NewContext(top, module.Usage(usage))
+			if err := setSnapshotTime(c, ctx); err != nil {
+				return err
+			}
+			foldersmod := module.NewFoldersModule(ctx, false, opt.recursive)
+			depthmod := module.NewDepthModule(foldersmod, scanOptions.MaxDepth)
 			totalmod := module.NewTotalModule(ctx)
-			detailfilemod := module.NewDetailFileModule(opt.vrange)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			detailfilemod := module.NewDetailFileModule(ctx, opt.vrange)
 			totalfilemod := module.NewAggregateFileModule(ctx)
+			agemod := module.NewAgeModule(ctx, opt.atime)
 			extmod := module.NewExtensionModule(ctx, false)
 			topfilesmod := module.NewTopFilesModule(ctx)
 
-			run(opt.path, c, totalfilemod, extmod, topfilesmod, foldersmod, detailfilemod, totalmod)
-
-			return nil
+			return run(append(opt.paths, args...), c, totalfilemod, agemod, extmod, topfilesmod, foldersmod, depthmod, detailfilemod, rootsmod, errorsmod, mountsmod, totalmod)
 		},
 	}
 
 	configure(cmd, &opt)
+	confAtime(cmd, &opt.atime)
+	confRecursive(cmd, &opt.recursive)
 
 	return cmd
 }
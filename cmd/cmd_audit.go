@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/spf13/cobra"
+)
+
+func newAudit(c conf) *cobra.Command {
+	var paths []string
+	var fail bool
+
+	var cmd = &cobra.Command{
+		Use:   "audit [path...]",
+		Short: "Show files and folders with risky permissions or owners",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Rolled up folders are not reported so their subfolders can't be checked
+			if scanOptions.MaxDepth > 0 {
+				return errors.New("--depth can't be used with audit since deeper folders are not checked")
+			}
+
+			ctx := module.NewContext(top, module.Usage(usage))
+			auditmod := module.NewAuditModule(ctx, c.fs())
+			totalmod := module.NewTotalModule(ctx)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			foldersmod := module.NewFoldersModule(ctx, true, false)
+			extmod := module.NewExtensionModule(ctx, true)
+
+			err := run(append(paths, args...), c, extmod, foldersmod, auditmod, rootsmod, errorsmod, mountsmod, totalmod)
+			if err != nil {
+				return err
+			}
+
+			if fail && auditmod.Findings() > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d security audit findings", auditmod.Findings())
+			}
+			return nil
+		},
+	}
+
+	configurePath(cmd, &paths)
+	cmd.Flags().BoolVar(&fail, "fail", false, "Exit with nonzero code if anything found. By default false")
+
+	return cmd
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+	"time"
+)
+
+func newDiff(c conf) *cobra.Command {
+	var paths []string
+
+	var cmd = &cobra.Command{
+		Use:   "diff <snapshot> [snapshot | path...]",
+		Short: "Show what changed since snapshot was taken comparing it with another snapshot or path",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("snapshot to compare with required")
+			}
+
+			base, f, err := openSnapshot(c, args[0])
+			if err != nil {
+				return err
+			}
+			defer closeList(f)
+
+			ctx, cancel := newScanContext()
+			defer cancel()
+
+			color.Fprintf(c.w(), "Before: <red>%s</> taken at %s\n", args[0], base.Created.Format(time.RFC1123))
+
+			var current module.Source
+			targets := append(paths, args[1:]...)
+			if len(targets) == 1 && isFile(c, targets[0]) {
+				snapshot, f, err := openSnapshot(c, targets[0])
+				if err != nil {
+					return err
+				}
+				defer closeList(f)
+
+				color.Fprintf(c.w(), "After:  <red>%s</> taken at %s\n\n", targets[0], snapshot.Created.Format(time.RFC1123))
+				current = module.NewSnapshotSource(snapshot)
+			} else {
+				roots := correctPaths(targets, c.fs())
+				if len(roots) == 0 {
+					return errors.New("path or snapshot to compare with required")
+				}
+
+				opt, err := newScanOptions(roots, c)
+				if err != nil {
+					return err
+				}
+
+				for _, path := range roots {
+					color.Fprintf(c.w(), "After:  <red>%s</>\n", path)
+				}
+				color.Fprintln(c.w())
+				current = module.NewPathsSource(roots, c.fs(), opt)
+			}
+
+			ctxm := module.NewContext(top, module.Usage(usage))
+			return module.ExecuteDiff(ctx, module.NewSnapshotSource(base), current, c.w(), ctxm)
+		},
+	}
+
+	configurePath(cmd, &paths)
+
+	return cmd
+}
+
+// isFile gets whether path is a file i.e. not a folder
+func isFile(c conf, path string) bool {
+	fi, err := c.fs().Stat(path)
+	return err == nil && !fi.IsDir()
+}
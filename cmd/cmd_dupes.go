@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/spf13/cobra"
+)
+
+func newDupes(c conf) *cobra.Command {
+	var paths []string
+
+	var cmd = &cobra.Command{
+		Use:     "dupes [path...]",
+		Aliases: []string{"duplicates"},
+		Short:   "Show files with the same content ranked by the number of bytes freed if duplicates removed",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Files content is read when scanning completes so they must be scanned now.
+			// Files listed by --files-from are scanned too so it's allowed
+			if snapshotFile != "" {
+				return errors.New("duplicates can't be found in snapshot since files content is compared")
+			}
+
+			ctx := module.NewContext(top, module.Usage(usage))
+			dupesmod := module.NewDupesModule(ctx, c.fs())
+			totalmod := module.NewTotalModule(ctx)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			foldersmod := module.NewFoldersModule(ctx, true, false)
+			extmod := module.NewExtensionModule(ctx, true)
+
+			return run(append(paths, args...), c, extmod, foldersmod, dupesmod, rootsmod, errorsmod, mountsmod, totalmod)
+		},
+	}
+
+	configurePath(cmd, &paths)
+
+	return cmd
+}
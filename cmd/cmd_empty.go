@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/aegoroff/dirstat/module"
+	"github.com/spf13/cobra"
+)
+
+func newEmpty(c conf) *cobra.Command {
+	var paths []string
+
+	var cmd = &cobra.Command{
+		Use:   "empty [path...]",
+		Short: "Show empty files, empty folders and folders containing only empty subfolders",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := module.NewContext(top, module.Usage(usage))
+			emptymod := module.NewEmptyModule(ctx)
+			totalmod := module.NewTotalModule(ctx)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			foldersmod := module.NewFoldersModule(ctx, true, false)
+			extmod := module.NewExtensionModule(ctx, true)
+
+			return run(append(paths, args...), c, extmod, foldersmod, emptymod, rootsmod, errorsmod, mountsmod, totalmod)
+		},
+	}
+
+	configurePath(cmd, &paths)
+
+	return cmd
+}
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"dirstat/module"
+	"github.com/aegoroff/dirstat/module"
 	"github.com/spf13/cobra"
 )
 
@@ -11,26 +11,32 @@ func newFile(c conf) *cobra.Command {
 	showExtStatistic := false
 
 	var cmd = &cobra.Command{
-		Use:     "fi",
+		Use:     "fi [path...]",
 		Aliases: []string{"file"},
 		Short:   "Show information about files within folder on volume only",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := module.NewContext(top)
+			ctx := module.NewContext(top, module.Usage(usage))
+			if err := setSnapshotTime(c, ctx); err != nil {
+				return err
+			}
 			totalmod := module.NewTotalModule(ctx)
-			detailfilemod := module.NewDetailFileModule(opt.vrange)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			detailfilemod := module.NewDetailFileModule(ctx, opt.vrange)
 			totalfilemod := module.NewAggregateFileModule(ctx)
-			foldersmod := module.NewFoldersModule(ctx, true)
+			agemod := module.NewAgeModule(ctx, opt.atime)
+			foldersmod := module.NewFoldersModule(ctx, true, false)
 			extmod := module.NewExtensionModule(ctx, !showExtStatistic)
 
 			topfilesmod := module.NewTopFilesModule(ctx)
 
-			run(opt.path, c, totalfilemod, extmod, topfilesmod, detailfilemod, foldersmod, totalmod)
-
-			return nil
+			return run(append(opt.paths, args...), c, totalfilemod, agemod, extmod, topfilesmod, detailfilemod, foldersmod, rootsmod, errorsmod, mountsmod, totalmod)
 		},
 	}
 
 	configure(cmd, &opt)
+	confAtime(cmd, &opt.atime)
 
 	cmd.Flags().BoolVarP(&showExtStatistic, "ext", "e", false, "Show extensions statistic. By default false")
 
@@ -1,30 +1,34 @@
 package cmd
 
 import (
-	"dirstat/module"
+	"github.com/aegoroff/dirstat/module"
 	"github.com/spf13/cobra"
 )
 
 func newFolder(c conf) *cobra.Command {
-	var path string
+	var paths []string
+	var recursive bool
 
 	var cmd = &cobra.Command{
-		Use:     "fo",
+		Use:     "fo [path...]",
 		Aliases: []string{"folder"},
 		Short:   "Show information about folders within folder on volume only",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ctx := module.NewContext(top)
-			foldersmod := module.NewFoldersModule(ctx, false)
+			ctx := module.NewContext(top, module.Usage(usage))
+			foldersmod := module.NewFoldersModule(ctx, false, recursive)
+			depthmod := module.NewDepthModule(foldersmod, scanOptions.MaxDepth)
 			totalmod := module.NewTotalModule(ctx)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
 			extmod := module.NewExtensionModule(ctx, true)
 
-			run(path, c, extmod, foldersmod, totalmod)
-
-			return nil
+			return run(append(paths, args...), c, extmod, foldersmod, depthmod, rootsmod, errorsmod, mountsmod, totalmod)
 		},
 	}
 
-	configurePath(cmd, &path)
+	configurePath(cmd, &paths)
+	confRecursive(cmd, &recursive)
 
 	return cmd
 }
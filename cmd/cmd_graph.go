@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/spf13/cobra"
+)
+
+func newGraph(c conf) *cobra.Command {
+	var paths []string
+	var output string
+	var graphml bool
+	var limit int
+
+	var cmd = &cobra.Command{
+		Use:   "graph [path...]",
+		Short: "Save folders graph with edges weighted by size in Graphviz DOT or GraphML format",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				return errors.New("graph file path required")
+			}
+
+			f, err := createOutput(c, output)
+			if err != nil {
+				return err
+			}
+
+			format := module.GraphDOT
+			if graphml {
+				format = module.GraphML
+			}
+
+			ctx := module.NewContext(top, module.Usage(usage))
+			graphmod := module.NewGraphModule(ctx, f, format, limit)
+
+			err = run(append(paths, args...), c, graphmod)
+
+			return closeOutput(c, f, output, err)
+		},
+	}
+
+	configurePath(cmd, &paths)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "REQUIRED. Graph file path.")
+	cmd.Flags().BoolVar(&graphml, "graphml", false, "Save graph in GraphML format instead of DOT. By default false")
+	cmd.Flags().IntVar(&limit, "max-folders", 500, "The max number of folders in graph. The largest folders are kept and others are replaced by pruned nodes. 0 means no limit")
+
+	return cmd
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/aegoroff/dirstat/module"
+	"github.com/spf13/cobra"
+)
+
+func newOwners(c conf) *cobra.Command {
+	var paths []string
+
+	var cmd = &cobra.Command{
+		Use:     "ow [path...]",
+		Aliases: []string{"owners"},
+		Short:   "Show information about files owners users and groups",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := module.NewContext(top, module.Usage(usage))
+			ownersmod := module.NewOwnersModule(ctx, c.fs())
+			totalmod := module.NewTotalModule(ctx)
+			rootsmod := module.NewRootsModule(ctx)
+			errorsmod := module.NewErrorsModule(ctx, false)
+			mountsmod := module.NewMountsModule()
+			foldersmod := module.NewFoldersModule(ctx, true, false)
+			extmod := module.NewExtensionModule(ctx, true)
+
+			return run(append(paths, args...), c, extmod, foldersmod, ownersmod, rootsmod, errorsmod, mountsmod, totalmod)
+		},
+	}
+
+	configurePath(cmd, &paths)
+
+	return cmd
+}
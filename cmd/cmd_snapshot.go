@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshot(c conf) *cobra.Command {
+	var paths []string
+	var output string
+
+	var cmd = &cobra.Command{
+		Use:     "snapshot [path...]",
+		Aliases: []string{"snap"},
+		Short:   "Save scanning results into file to show information later using --snapshot option",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output == "" {
+				return errors.New("snapshot file path required")
+			}
+
+			// Paths are validated before so as existing
+			// snapshot isn't replaced by empty one
+			paths = append(paths, args...)
+			if snapshotFile == "" && filesFrom == "" && len(correctPaths(paths, c.fs())) == 0 {
+				return errors.New("existing path to scan required")
+			}
+
+			f, err := createOutput(c, output)
+			if err != nil {
+				return err
+			}
+
+			writer, err := scan.NewSnapshotWriter(f)
+			if err != nil {
+				return closeOutput(c, f, output, err)
+			}
+
+			snapshotmod := module.NewSnapshotModule(writer)
+
+			err = run(paths, c, snapshotmod)
+
+			if werr := writer.Close(); err == nil {
+				err = werr
+			}
+			return closeOutput(c, f, output, err)
+		},
+	}
+
+	configurePath(cmd, &paths)
+	cmd.Flags().StringVarP(&output, "output", "o", "", "REQUIRED. Snapshot file path.")
+
+	return cmd
+}
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+	"github.com/aegoroff/dirstat/module"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"io"
@@ -8,8 +10,10 @@ import (
 )
 
 type options struct {
-	vrange []int
-	path   string
+	vrange    []int
+	paths     []string
+	recursive bool
+	atime     bool
 }
 
 type conf interface {
@@ -18,11 +22,19 @@ type conf interface {
 
 	// w defines app output
 	w() io.Writer
+
+	// ew defines app diagnostic output like scanning progress
+	ew() io.Writer
+
+	// r defines app input like files list
+	r() io.Reader
 }
 
 type appConf struct {
 	filesystem afero.Fs
 	writer     io.Writer
+	errWriter  io.Writer
+	reader     io.Reader
 }
 
 func (a *appConf) fs() afero.Fs {
@@ -33,16 +45,52 @@ func (a *appConf) w() io.Writer {
 	return a.writer
 }
 
+func (a *appConf) ew() io.Writer {
+	return a.errWriter
+}
+
+func (a *appConf) r() io.Reader {
+	return a.reader
+}
+
+// usageValue implements pflag.Value interface to parse module.Usage from command line
+type usageValue module.Usage
+
+func (u *usageValue) String() string {
+	if module.Usage(*u) == module.UsageDisk {
+		return "disk"
+	}
+	return "apparent"
+}
+
+func (u *usageValue) Set(s string) error {
+	switch s {
+	case "apparent":
+		*u = usageValue(module.UsageApparent)
+	case "disk":
+		*u = usageValue(module.UsageDisk)
+	default:
+		return fmt.Errorf("invalid usage %q. Must be apparent or disk", s)
+	}
+	return nil
+}
+
+func (u *usageValue) Type() string {
+	return "string"
+}
+
 func newAppConf() conf {
 	c := appConf{
 		filesystem: afero.NewOsFs(),
 		writer:     os.Stdout,
+		errWriter:  os.Stderr,
+		reader:     os.Stdin,
 	}
 	return &c
 }
 
 func configure(cmd *cobra.Command, opt *options) {
-	configurePath(cmd, &opt.path)
+	configurePath(cmd, &opt.paths)
 	confRange(cmd, &opt.vrange)
 }
 
@@ -50,6 +98,14 @@ func confRange(cmd *cobra.Command, rn *[]int) {
 	cmd.Flags().IntSliceVarP(rn, "range", "r", []int{}, "Output verbose files info for range specified. Range is the number between 1 and 10")
 }
 
-func configurePath(cmd *cobra.Command, path *string) {
-	cmd.Flags().StringVarP(path, "path", "p", "", "REQUIRED. Directory path to show info.")
+func configurePath(cmd *cobra.Command, paths *[]string) {
+	cmd.Flags().StringArrayVarP(paths, "path", "p", []string{}, "REQUIRED. Directory path to show info. Can be set several times (or paths can be passed as arguments) to show combined info")
+}
+
+func confRecursive(cmd *cobra.Command, recursive *bool) {
+	cmd.Flags().BoolVarP(recursive, "recursive", "R", false, "Rank folders by size and count including all subfolders. By default false")
+}
+
+func confAtime(cmd *cobra.Command, atime *bool) {
+	cmd.Flags().BoolVar(atime, "atime", false, "Show files age by the last access time instead of modification time. By default false")
 }
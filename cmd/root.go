@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	"github.com/aegoroff/dirstat/module/scan"
 	"github.com/spf13/cobra"
 	"os"
+	"time"
 )
 
 func newRoot() *cobra.Command {
@@ -22,6 +24,17 @@ func init() {
 
 var showMemory bool
 var top int
+var timeout time.Duration
+var scanOptions scan.Options
+var usage usageValue
+var include []string
+var exclude []string
+var gitignore bool
+var dockerignore bool
+var tagIgnored bool
+var filesFrom string
+var nullSeparated bool
+var snapshotFile string
 
 // Execute starts package running
 func Execute(args ...string) {
@@ -32,6 +45,23 @@ func Execute(args ...string) {
 	}
 
 	rootCmd.PersistentFlags().IntVarP(&top, "top", "t", 10, "The number of lines in top statistics.")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "Stop scanning after the time specified (for example 30s or 5m) and show partial results. By default no timeout")
+	rootCmd.PersistentFlags().IntVarP(&scanOptions.Workers, "workers", "w", 0, "The number of folders read concurrently. By default 32")
+	rootCmd.PersistentFlags().IntVar(&scanOptions.DirsPerSecond, "dirs-rate", 0, "Read no more than the number of folders specified per second. By default no limit")
+	rootCmd.PersistentFlags().IntVar(&scanOptions.EntriesPerSecond, "entries-rate", 0, "Read no more than the number of files and folders specified per second. By default no limit")
+	rootCmd.PersistentFlags().BoolVarP(&scanOptions.FollowSymlinks, "follow-symlinks", "L", false, "Follow symbolic links. Each target folder or file is counted once. By default false")
+	rootCmd.PersistentFlags().BoolVarP(&scanOptions.OneFileSystem, "one-file-system", "x", false, "Skip folders on other file systems than the path specified (mount points). By default false")
+	rootCmd.PersistentFlags().StringArrayVar(&include, "include", []string{}, "Glob pattern (** matches any folders) of files to include. Can be set several times. By default all files included")
+	rootCmd.PersistentFlags().StringArrayVar(&exclude, "exclude", []string{}, "Glob pattern (** matches any folders) of files and folders to exclude. Excluded folders are not read. Can be set several times")
+	rootCmd.PersistentFlags().BoolVar(&gitignore, "gitignore", false, "Skip files and folders ignored by .gitignore files and .git folder. By default false")
+	rootCmd.PersistentFlags().BoolVar(&dockerignore, "dockerignore", false, "Skip files and folders ignored by .dockerignore file in the path specified. By default false")
+	rootCmd.PersistentFlags().BoolVar(&tagIgnored, "tag-ignored", false, "Don't skip files ignored by --gitignore or --dockerignore but show ignored and not ignored files totals. By default false")
+	rootCmd.PersistentFlags().IntVarP(&scanOptions.MaxDepth, "depth", "d", 0, "Show folders up to the depth specified with deeper folders content included into their ancestor at that depth. By default no limit")
+	rootCmd.PersistentFlags().BoolVar(&scanOptions.Archives, "archives", false, "Read zip, jar, tar and tar.gz archives content and show it like folders content in extensions, top files and folders statistic. Archives themselves are counted in totals at their size on disk. By default false")
+	rootCmd.PersistentFlags().StringVar(&filesFrom, "files-from", "", "Scan files listed in the file specified (- for standard input) instead of walking path. Paths are separated by new line")
+	rootCmd.PersistentFlags().BoolVarP(&nullSeparated, "null", "0", false, "Paths in --files-from list are separated by NUL character like find -print0 and git ls-files -z output. By default false")
+	rootCmd.PersistentFlags().StringVar(&snapshotFile, "snapshot", "", "Show information from the snapshot file specified (taken by snapshot command) instead of scanning path. Scanning options are applied when the snapshot is taken")
+	rootCmd.PersistentFlags().VarP(&usage, "usage", "u", "File size to show and rank by: apparent (the number of bytes in a file) or disk (space allocated on disk)")
 	rootCmd.PersistentFlags().BoolVarP(&showMemory, "memory", "m", false, "Show memory statistic after run")
 
 	conf := newAppConf()
@@ -39,6 +69,13 @@ func Execute(args ...string) {
 	rootCmd.AddCommand(newAll(conf))
 	rootCmd.AddCommand(newFile(conf))
 	rootCmd.AddCommand(newFolder(conf))
+	rootCmd.AddCommand(newSnapshot(conf))
+	rootCmd.AddCommand(newDiff(conf))
+	rootCmd.AddCommand(newGraph(conf))
+	rootCmd.AddCommand(newDupes(conf))
+	rootCmd.AddCommand(newOwners(conf))
+	rootCmd.AddCommand(newAudit(conf))
+	rootCmd.AddCommand(newEmpty(conf))
 	rootCmd.AddCommand(newVersion(conf.w()))
 
 	if err := rootCmd.Execute(); err != nil {
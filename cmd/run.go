@@ -1,36 +1,265 @@
 package cmd
 
 import (
-	"dirstat/module"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aegoroff/dirstat/module"
+	"github.com/aegoroff/dirstat/module/scan"
 	"github.com/dustin/go-humanize"
 	"github.com/gookit/color"
 	"github.com/spf13/afero"
 	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 )
 
-type runner func(path string, fs afero.Fs, w io.Writer, modules ...module.Module)
+type runner func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module)
+
+// newScanOptions creates scanning options from command line flags
+func newScanOptions(paths []string, c conf) (scan.Options, error) {
+	opt := scanOptions
+
+	var globs scan.Filter
+	if len(include) > 0 || len(exclude) > 0 {
+		var err error
+		globs, err = scan.NewGlobFilter(include, exclude)
+		if err != nil {
+			return opt, err
+		}
+	}
+
+	var ignore scan.Filter
+	if gitignore || dockerignore {
+		// Files list paths are matched as they are i.e. relatively to the current folder
+		var root string
+		if len(paths) > 0 && filesFrom == "" {
+			root = paths[0]
+		}
+		ignore = scan.NewIgnoreFilter(c.fs(), root, gitignore, dockerignore)
+	}
+
+	if tagIgnored {
+		opt.Filter = globs
+		opt.Ignore = ignore
+	} else {
+		opt.Filter = scan.NewFilters(globs, ignore)
+	}
+
+	if isTerminal(c.ew()) {
+		opt.Progress = newProgressPrinter(c.ew())
+	}
+
+	return opt, nil
+}
+
+func run(paths []string, c conf, modules ...module.Module) error {
+	opt, err := newScanOptions(paths, c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newScanContext()
+	defer cancel()
 
-func run(path string, c conf, modules ...module.Module) {
 	var r runner
-	{
+	switch {
+	case snapshotFile != "" && filesFrom != "":
+		return errors.New("--snapshot and --files-from can't be used together")
+	case snapshotFile != "":
+		snapshot, f, err := openSnapshot(c, snapshotFile)
+		if err != nil {
+			return err
+		}
+		defer closeList(f)
+
+		r = newSnapshotR(snapshot)
+		r = newTimeMeasureR(r)
+		r = newPrintMemoryR(r)
+	case filesFrom != "":
+		name, list, err := openList(c)
+		if err != nil {
+			return err
+		}
+		defer closeList(list)
+
+		r = newListR(name, list)
+		r = newTimeMeasureR(r)
+		r = newPrintMemoryR(r)
+	default:
 		r = module.Execute
 		r = newTimeMeasureR(r)
 		r = newPrintMemoryR(r)
 		r = newPathCorrectionR(r)
 	}
 
-	r(path, c.fs(), c.w(), modules...)
+	r(ctx, paths, c.fs(), c.w(), opt, modules...)
+
+	return nil
+}
+
+// openSnapshot opens snapshot file for reading. The file must be closed after reading
+func openSnapshot(c conf, path string) (*scan.SnapshotReader, afero.File, error) {
+	f, err := c.fs().Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snapshot, err := scan.NewSnapshotReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return snapshot, f, nil
+}
+
+// setSnapshotTime makes files age measured from the time the snapshot shown was taken
+// instead of now so as it doesn't depend on when the snapshot is shown
+func setSnapshotTime(c conf, ctx *module.Context) error {
+	if snapshotFile == "" {
+		return nil
+	}
+	snapshot, f, err := openSnapshot(c, snapshotFile)
+	if err != nil {
+		return err
+	}
+	defer closeList(f)
+
+	ctx.SetNow(snapshot.Created)
+	return nil
+}
+
+// openList opens files list set by --files-from. It returns list name to show
+func openList(c conf) (string, io.Reader, error) {
+	if filesFrom == "-" {
+		return "stdin", c.r(), nil
+	}
+	f, err := c.fs().Open(filesFrom)
+	if err != nil {
+		return "", nil, err
+	}
+	return filesFrom, f, nil
+}
+
+func closeList(list io.Reader) {
+	if c, ok := list.(afero.File); ok {
+		_ = c.Close()
+	}
+}
+
+// createOutput creates temporary file beside output to write into. It replaces
+// output only when closed by closeOutput after success so as existing output
+// isn't truncated if command fails (for example it's the snapshot shown)
+func createOutput(c conf, output string) (afero.File, error) {
+	return c.fs().Create(output + ".tmp")
+}
+
+// closeOutput closes file created by createOutput and renames it to output
+// if err is nil or removes it otherwise. It returns err or closing failure
+func closeOutput(c conf, f afero.File, output string, err error) error {
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = c.fs().Rename(f.Name(), output)
+	}
+	if err != nil {
+		_ = c.fs().Remove(f.Name())
+	}
+	return err
+}
+
+// isTerminal gets whether w is a terminal (character device)
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// newProgressPrinter creates progress handler that outputs progress line
+// refreshing it in place. The line is cleared when scanning completes
+func newProgressPrinter(w io.Writer) scan.ProgressHandler {
+	const maxPath = 60
+	const clearLine = "\r\033[K"
+
+	return func(p *scan.Progress) {
+		if p.Done {
+			_, _ = fmt.Fprint(w, clearLine)
+			return
+		}
+
+		path := p.Path
+		// Truncated by runes so as multi-byte characters are not split
+		if r := []rune(path); len(r) > maxPath {
+			path = "..." + string(r[len(r)-maxPath+3:])
+		}
+
+		_, _ = fmt.Fprintf(w, "%sFolders: %d  Files: %d  Size: %s  Queue: %d  %s",
+			clearLine, p.Folders, p.Files, humanize.IBytes(uint64(p.Bytes)), p.Queue, path)
+	}
+}
+
+// newScanContext creates context that is cancelled on Ctrl-C or
+// when timeout expires if it was set
+func newScanContext() (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	go func() {
+		defer signal.Stop(interrupt)
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// newListR creates runner that scans files from list instead of paths
+func newListR(name string, list io.Reader) runner {
+	return func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module) {
+		var sep byte = '\n'
+		if nullSeparated {
+			sep = 0
+		}
+
+		color.Fprintf(w, "Files list: <red>%s</>\n\n", name)
+
+		module.ExecuteSource(ctx, module.NewListSource(name, list, sep, fs, opt), w, modules...)
+	}
+}
+
+// newSnapshotR creates runner that reads events from snapshot instead of scanning paths
+func newSnapshotR(snapshot *scan.SnapshotReader) runner {
+	return func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module) {
+		color.Fprintf(w, "Snapshot: <red>%s</> taken at %s\n\n", snapshotFile, snapshot.Created.Format(time.RFC1123))
+
+		module.ExecuteSource(ctx, module.NewSnapshotSource(snapshot), w, modules...)
+	}
 }
 
 func newTimeMeasureR(wrapped runner) runner {
-	return func(path string, fs afero.Fs, w io.Writer, modules ...module.Module) {
+	return func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module) {
 		start := time.Now()
 
-		wrapped(path, fs, w, modules...)
+		wrapped(ctx, paths, fs, w, opt, modules...)
 
 		elapsed := time.Since(start)
 
@@ -39,26 +268,72 @@ func newTimeMeasureR(wrapped runner) runner {
 }
 
 func newPathCorrectionR(wrapped runner) runner {
-	return func(path string, fs afero.Fs, w io.Writer, modules ...module.Module) {
-		if _, err := fs.Stat(path); os.IsNotExist(err) {
+	return func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module) {
+		roots := correctPaths(paths, fs)
+		if len(roots) == 0 {
 			return
 		}
 
+		for _, path := range roots {
+			color.Fprintf(w, "Root: <red>%s</>\n", path)
+		}
+		_, _ = fmt.Fprintln(w)
+
+		wrapped(ctx, roots, fs, w, opt, modules...)
+	}
+}
+
+// correctPaths removes missing paths, paths within other paths and duplicates.
+// Windows drive letter is turned into the drive root
+func correctPaths(paths []string, fs afero.Fs) []string {
+	var roots []string
+	for _, path := range paths {
+		if _, err := fs.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+
 		if (path)[len(path)-1] == ':' {
 			path = filepath.Join(path, "\\")
 		}
 
-		color.Fprintf(w, "Root: <red>%s</>\n\n", path)
+		roots = append(roots, path)
+	}
+
+	return outermost(roots)
+}
 
-		wrapped(path, fs, w, modules...)
+// outermost removes duplicate paths and paths within other paths
+// so as each file is scanned once. Order is preserved
+func outermost(paths []string) []string {
+	var result []string
+	for i, path := range paths {
+		nested := false
+		for j, other := range paths {
+			if i == j {
+				continue
+			}
+			rel, err := filepath.Rel(other, path)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			// The same path is kept at its first occurrence
+			if rel != "." || j < i {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			result = append(result, path)
+		}
 	}
+	return result
 }
 
 // newPrintMemoryR outputs the current, total and OS memory being used. As well as the number
 // of garage collection cycles completed.
 func newPrintMemoryR(wrapped runner) runner {
-	return func(path string, fs afero.Fs, w io.Writer, modules ...module.Module) {
-		wrapped(path, fs, w, modules...)
+	return func(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...module.Module) {
+		wrapped(ctx, paths, fs, w, opt, modules...)
 
 		if !showMemory {
 			return
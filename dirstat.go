@@ -1,6 +1,6 @@
 package main
 
-import "dirstat/cmd"
+import "github.com/aegoroff/dirstat/cmd"
 
 func main() {
 	cmd.Execute()
@@ -0,0 +1,179 @@
+package module
+
+import (
+	"fmt"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/spf13/afero"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// homeFolders defines folders where users home folders are
+var homeFolders = []string{"/home/", "/root/", "/Users/"}
+
+type finding int
+
+const (
+	findingWorldWritableFiles finding = iota
+	findingWorldWritableFolders
+	findingSetuid
+	findingSetgid
+	findingOrphans
+	findingHomeGroupWritable
+	findingsCount
+)
+
+var findingTitles = [findingsCount]string{
+	"World-writable files",
+	"World-writable folders without sticky bit",
+	"Setuid files",
+	"Setgid files",
+	"Files and folders of removed users",
+	"Group-writable files in home folders",
+}
+
+// auditWorker finds files and folders with risky permissions or owners
+type auditWorker struct {
+	voidInit
+	usage    Usage
+	accounts *sys.Accounts
+	found    [findingsCount]files
+	findings int64
+}
+
+type auditRenderer struct {
+	*auditWorker
+	top int
+}
+
+type auditModule struct {
+	Module
+	work *auditWorker
+}
+
+func newAuditWorker(ctx *Context, fs afero.Fs) *auditWorker {
+	return &auditWorker{
+		usage:    ctx.usage,
+		accounts: sys.ReadAccounts(fs),
+	}
+}
+
+func newAuditRenderer(work *auditWorker, top int) renderer {
+	return &auditRenderer{auditWorker: work, top: top}
+}
+
+// Worker methods
+
+func (m *auditWorker) finalize() {
+	for i := range m.found {
+		sort.Sort(sort.Reverse(m.found[i]))
+		m.findings += int64(len(m.found[i]))
+	}
+}
+
+func (m *auditWorker) handler(evt *scan.Event) {
+	switch {
+	case evt.File != nil:
+		m.onFile(evt.File)
+	case evt.Folder != nil:
+		m.onFolder(evt.Folder)
+	}
+}
+
+func (m *auditWorker) onFile(f *scan.FileEntry) {
+	// Archive members permissions and owners are not applied to any file on disk
+	if f.Archive != "" || !f.ModeKnown {
+		return
+	}
+
+	perm := f.Mode.Perm()
+	if perm&0002 != 0 {
+		m.add(findingWorldWritableFiles, f.Path, m.usage.size(f))
+	}
+	if f.Mode&os.ModeSetuid != 0 {
+		m.add(findingSetuid, f.Path, m.usage.size(f))
+	}
+	if f.Mode&os.ModeSetgid != 0 {
+		m.add(findingSetgid, f.Path, m.usage.size(f))
+	}
+	if f.OwnerKnown && m.accounts.Orphan(f.UID) {
+		m.add(findingOrphans, f.Path, m.usage.size(f))
+	}
+	if perm&0020 != 0 && inHome(f.Path) {
+		m.add(findingHomeGroupWritable, f.Path, m.usage.size(f))
+	}
+}
+
+func (m *auditWorker) onFolder(f *scan.FolderEntry) {
+	// Mode isn't known if folder info isn't available
+	if !f.ModeKnown {
+		return
+	}
+
+	size := m.usage.size(&f.FileEntry)
+	if f.Mode.Perm()&0002 != 0 && f.Mode&os.ModeSticky == 0 {
+		m.add(findingWorldWritableFolders, f.Path, size)
+	}
+	if f.OwnerKnown && m.accounts.Orphan(f.UID) {
+		m.add(findingOrphans, f.Path, size)
+	}
+}
+
+func (m *auditWorker) add(f finding, path string, size int64) {
+	m.found[f] = append(m.found[f], &file{path: path, size: size})
+}
+
+// Findings gets the number of files and folders found
+func (m *auditModule) Findings() int64 {
+	return m.work.findings
+}
+
+// inHome gets whether the path is within users home folders.
+// Relative path is resolved against the current folder
+func inHome(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	path = filepath.ToSlash(strings.TrimPrefix(path, filepath.VolumeName(path)))
+
+	for _, h := range homeFolders {
+		if strings.HasPrefix(path, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// Renderer method
+
+func (m *auditRenderer) print(p printer) {
+	p.cprint("<gray>Security audit:</>\n\n")
+
+	p.print("%v\t%v\n", "Finding", "Count")
+	p.print("%v\t%v\n", "-------", "-----")
+	for i, found := range m.found {
+		p.print("%v\t%v\n", findingTitles[i], len(found))
+	}
+	p.flush()
+
+	for i, found := range m.found {
+		if len(found) == 0 {
+			continue
+		}
+
+		p.cprint("\n<gray>TOP %d %s by size:</>\n\n", m.top, strings.ToLower(findingTitles[i]))
+
+		p.print("%v\t%v\n", "Path", "Size")
+		p.print("%v\t%v\n", "----", "----")
+
+		for j := 0; j < m.top && j < len(found); j++ {
+			h := fmt.Sprintf("%2d. %s", j+1, found[j].path)
+			p.print("%v\t%v\n", h, human(found[j].size))
+		}
+
+		p.flush()
+	}
+}
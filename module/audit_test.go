@@ -0,0 +1,42 @@
+package module
+
+import (
+	"os"
+	"testing"
+)
+
+func TestInHome(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Relative paths are resolved against the current folder
+	if err := os.Chdir("/"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	var tests = []struct {
+		path string
+		want bool
+	}{
+		{"/home/u/f", true},
+		{"/root/f", true},
+		{"/Users/u/f", true},
+		{"/etc/f", false},
+		{"/homework/f", false},
+		{"home/u/f", true},
+		{"./root/f", true},
+		{"etc/../Users/u/f", true},
+		{"etc/f", false},
+		{"f", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if got := inHome(test.path); got != test.want {
+				t.Errorf("inHome(%q) = %v, want %v", test.path, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package module
+
+import (
+	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	inBase    = 1
+	inCurrent = 2
+)
+
+// folderDelta contains folder sizes in both compared states.
+// Total fields include all subfolders
+type folderDelta struct {
+	present      int
+	base         int64
+	current      int64
+	baseTotal    int64
+	currentTotal int64
+}
+
+func (f *folderDelta) delta() int64 { return f.currentTotal - f.baseTotal }
+
+// fileID identifies file on a volume. Zero if not available
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// inodes contains files already summed so as hard links to the same inode
+// are summed once. Each total has its own set since hard links to the inode
+// have the same size and so the same change whichever of them is summed
+type inodes map[fileID]bool
+
+// add adds id and gets whether it wasn't added before.
+// Files without id known are always summed
+func (s inodes) add(id fileID) bool {
+	if id == (fileID{}) {
+		return true
+	}
+	if s[id] {
+		return false
+	}
+	s[id] = true
+	return true
+}
+
+// baseFile contains base state file size and id
+type baseFile struct {
+	size int64
+	id   fileID
+}
+
+// diffWorker compares base state events with current state events.
+// Base events must be handled first
+type diffWorker struct {
+	voidInit
+	usage Usage
+
+	// base set while base state events are handled
+	base bool
+
+	// baseFiles contains base state files. Files found in current state are deleted
+	// so as only removed files remain after current state handled
+	baseFiles map[string]baseFile
+
+	added   files
+	removed files
+	grown   files
+	shrunk  files
+
+	folders map[string]*folderDelta
+	exts    map[string]*extDelta
+
+	addedTotal   countSizeAggregate
+	removedTotal countSizeAggregate
+	grownTotal   countSizeAggregate
+	shrunkTotal  countSizeAggregate
+
+	// Inodes summed into totals and extensions sizes of both states
+	addedInodes   inodes
+	removedInodes inodes
+	changedInodes inodes
+	baseExts      inodes
+	currentExts   inodes
+
+	// foldersByPresence contains the number of folders by states they are present in
+	foldersByPresence map[int]int64
+}
+
+type extDelta struct {
+	count int64
+	size  int64
+}
+
+type diffRenderer struct {
+	*diffWorker
+	top int
+}
+
+func newDiffWorker(ctx *Context) *diffWorker {
+	return &diffWorker{
+		usage:             ctx.usage,
+		baseFiles:         make(map[string]baseFile),
+		folders:           make(map[string]*folderDelta),
+		exts:              make(map[string]*extDelta),
+		foldersByPresence: make(map[int]int64),
+		addedInodes:       make(inodes),
+		removedInodes:     make(inodes),
+		changedInodes:     make(inodes),
+		baseExts:          make(inodes),
+		currentExts:       make(inodes),
+	}
+}
+
+func newDiffRenderer(work *diffWorker, top int) renderer {
+	return &diffRenderer{diffWorker: work, top: top}
+}
+
+// Worker methods
+
+func (m *diffWorker) finalize() {
+	for path, bf := range m.baseFiles {
+		m.removed = append(m.removed, &file{path: path, size: bf.size})
+		m.removedTotal.Count++
+		if m.removedInodes.add(bf.id) {
+			m.removedTotal.Size += uint64(bf.size)
+		}
+	}
+
+	// Roll own sizes up into all ancestors the same way folders module does it
+	for path, fd := range m.folders {
+		for child, parent := path, filepath.Dir(path); parent != child; child, parent = parent, filepath.Dir(parent) {
+			p, ok := m.folders[parent]
+			if !ok {
+				break
+			}
+			p.baseTotal += fd.base
+			p.currentTotal += fd.current
+		}
+		m.foldersByPresence[fd.present]++
+	}
+
+	sort.Sort(sort.Reverse(m.added))
+	sort.Sort(sort.Reverse(m.removed))
+	sort.Sort(sort.Reverse(m.grown))
+	sort.Sort(m.shrunk)
+}
+
+func (m *diffWorker) handler(evt *scan.Event) {
+	switch {
+	case evt.File != nil:
+		// Archive members are changes of the archive file itself
+		if evt.File.Archive == "" {
+			m.onFile(evt.File)
+		}
+	case evt.Folder != nil:
+		if evt.Folder.Archive == "" {
+			m.onFolder(evt.Folder)
+		}
+	}
+}
+
+// onFile compares each path at its own size since which of hard links is counted
+// may differ between scans. Hard links to the same inode are summed once into totals
+func (m *diffWorker) onFile(f *scan.FileEntry) {
+	size := m.usage.size(f)
+	id := fileID{dev: f.Device, ino: f.Inode}
+
+	ext := filepath.Ext(f.Path)
+	e, ok := m.exts[ext]
+	if !ok {
+		e = &extDelta{}
+		m.exts[ext] = e
+	}
+
+	if m.base {
+		m.baseFiles[f.Path] = baseFile{size: size, id: id}
+		e.count--
+		if m.baseExts.add(id) {
+			e.size -= size
+		}
+		return
+	}
+	e.count++
+	if m.currentExts.add(id) {
+		e.size += size
+	}
+
+	old, ok := m.baseFiles[f.Path]
+	if !ok {
+		m.added = append(m.added, &file{path: f.Path, size: size})
+		m.addedTotal.Count++
+		if m.addedInodes.add(id) {
+			m.addedTotal.Size += uint64(size)
+		}
+		return
+	}
+	delete(m.baseFiles, f.Path)
+
+	delta := size - old.size
+	if delta == 0 {
+		return
+	}
+	summed := m.changedInodes.add(id)
+	if delta > 0 {
+		m.grown = append(m.grown, &file{path: f.Path, size: delta})
+		m.grownTotal.Count++
+		if summed {
+			m.grownTotal.Size += uint64(delta)
+		}
+	} else {
+		m.shrunk = append(m.shrunk, &file{path: f.Path, size: delta})
+		m.shrunkTotal.Count++
+		if summed {
+			m.shrunkTotal.Size += uint64(-delta)
+		}
+	}
+}
+
+func (m *diffWorker) onFolder(fe *scan.FolderEntry) {
+	size := m.usage.size(&fe.FileEntry)
+
+	fd, ok := m.folders[fe.Path]
+	if !ok {
+		fd = &folderDelta{}
+		m.folders[fe.Path] = fd
+	}
+
+	if m.base {
+		fd.present |= inBase
+		fd.base += size
+		fd.baseTotal += size
+	} else {
+		fd.present |= inCurrent
+		fd.current += size
+		fd.currentTotal += size
+	}
+}
+
+// Renderer method
+
+func (m *diffRenderer) print(p printer) {
+	const format = "%v\t%v\t%v\n"
+
+	p.cprint("<gray>Changes:</>\n\n")
+
+	p.print(format, "Files", "Count", "Size")
+	p.print(format, "-----", "-----", "----")
+	p.print(format, "Added", m.addedTotal.Count, signed(int64(m.addedTotal.Size)))
+	p.print(format, "Removed", m.removedTotal.Count, signed(-int64(m.removedTotal.Size)))
+	p.print(format, "Grown", m.grownTotal.Count, signed(int64(m.grownTotal.Size)))
+	p.print(format, "Shrunk", m.shrunkTotal.Count, signed(-int64(m.shrunkTotal.Size)))
+	p.flush()
+
+	p.cprint("\n")
+	p.print("%v\t%v\n", "Folders", "Count")
+	p.print("%v\t%v\n", "-------", "-----")
+	p.print("%v\t%v\n", "Added", m.foldersByPresence[inCurrent])
+	p.print("%v\t%v\n", "Removed", m.foldersByPresence[inBase])
+	p.flush()
+
+	m.printFiles(p, "TOP %d added files by size:", m.added)
+	m.printFiles(p, "TOP %d removed files by size:", m.removed)
+	m.printFiles(p, "TOP %d grown files by size change:", m.grown)
+	m.printFiles(p, "TOP %d shrunk files by size change:", m.shrunk)
+
+	m.printFolders(p)
+	m.printExtensions(p)
+}
+
+func (m *diffRenderer) printFiles(p printer, title string, data files) {
+	if len(data) == 0 {
+		return
+	}
+
+	p.cprint("\n<gray>"+title+"</>\n\n", m.top)
+
+	p.print("%v\t%v\n", "File", "Size")
+	p.print("%v\t%v\n", "------", "----")
+
+	for i := 0; i < m.top && i < len(data); i++ {
+		h := fmt.Sprintf("%2d. %s", i+1, data[i].path)
+		p.print("%v\t%v\n", h, human(abs(data[i].size)))
+	}
+
+	p.flush()
+}
+
+func (m *diffRenderer) printFolders(p printer) {
+	var changed []string
+	for path, fd := range m.folders {
+		if fd.delta() != 0 {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return abs(m.folders[changed[i]].delta()) > abs(m.folders[changed[j]].delta())
+	})
+
+	const format = "%v\t%v\t%v\t%v\n"
+
+	p.cprint("\n<gray>TOP %d folders by size change (including subfolders):</>\n\n", m.top)
+
+	p.print(format, "Folder", "Before", "After", "Change")
+	p.print(format, "------", "------", "-----", "------")
+
+	for i := 0; i < m.top && i < len(changed); i++ {
+		fd := m.folders[changed[i]]
+		h := fmt.Sprintf("%2d. %s", i+1, changed[i])
+		p.print(format, h, human(fd.baseTotal), human(fd.currentTotal), signed(fd.delta()))
+	}
+
+	p.flush()
+}
+
+func (m *diffRenderer) printExtensions(p printer) {
+	var changed []string
+	for ext, e := range m.exts {
+		if e.size != 0 || e.count != 0 {
+			changed = append(changed, ext)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return abs(m.exts[changed[i]].size) > abs(m.exts[changed[j]].size)
+	})
+
+	const format = "%v\t%v\t%v\n"
+
+	p.cprint("\n<gray>TOP %d file extensions by size change:</>\n\n", m.top)
+
+	p.print(format, "Extension", "Count", "Size")
+	p.print(format, "---------", "-----", "----")
+
+	for i := 0; i < m.top && i < len(changed); i++ {
+		e := m.exts[changed[i]]
+		p.print(format, changed[i], fmt.Sprintf("%+d", e.count), signed(e.size))
+	}
+
+	p.flush()
+}
+
+// signed gets human readable size change with sign
+func signed(n int64) string {
+	switch {
+	case n > 0:
+		return "+" + human(n)
+	case n < 0:
+		return "-" + human(-n)
+	default:
+		return human(0)
+	}
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
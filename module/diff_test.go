@@ -0,0 +1,73 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+	"testing"
+)
+
+func linkEvent(path string, size int64, dup bool) *scan.Event {
+	return &scan.Event{File: &scan.FileEntry{Path: path, Size: size, Device: 1, Inode: 2, Links: 2, Duplicate: dup}}
+}
+
+func TestDiffWorker_HardLinks(t *testing.T) {
+	var tests = []struct {
+		name    string
+		base    []*scan.Event
+		current []*scan.Event
+		grown   countSizeAggregate
+		added   countSizeAggregate
+		removed countSizeAggregate
+		ext     int64
+	}{
+		{
+			"other link counted",
+			[]*scan.Event{linkEvent("/r/a", 10, false), linkEvent("/r/b", 10, true)},
+			[]*scan.Event{linkEvent("/r/b", 10, false), linkEvent("/r/a", 10, true)},
+			countSizeAggregate{}, countSizeAggregate{}, countSizeAggregate{}, 0,
+		},
+		{
+			"inode grown",
+			[]*scan.Event{linkEvent("/r/a", 10, false), linkEvent("/r/b", 10, true)},
+			[]*scan.Event{linkEvent("/r/b", 15, false), linkEvent("/r/a", 15, true)},
+			countSizeAggregate{Count: 2, Size: 5}, countSizeAggregate{}, countSizeAggregate{}, 5,
+		},
+		{
+			"links added",
+			nil,
+			[]*scan.Event{linkEvent("/r/a", 10, false), linkEvent("/r/b", 10, true)},
+			countSizeAggregate{}, countSizeAggregate{Count: 2, Size: 10}, countSizeAggregate{}, 10,
+		},
+		{
+			"links removed",
+			[]*scan.Event{linkEvent("/r/a", 10, false), linkEvent("/r/b", 10, true)},
+			nil,
+			countSizeAggregate{}, countSizeAggregate{}, countSizeAggregate{Count: 2, Size: 10}, -10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newDiffWorker(NewContext(10, UsageApparent))
+			w.base = true
+			for _, evt := range test.base {
+				w.handler(evt)
+			}
+			w.base = false
+			for _, evt := range test.current {
+				w.handler(evt)
+			}
+
+			w.finalize()
+
+			if w.grownTotal != test.grown || w.addedTotal != test.added || w.removedTotal != test.removed {
+				t.Errorf("grown %+v, added %+v, removed %+v, want %+v, %+v, %+v", w.grownTotal, w.addedTotal, w.removedTotal, test.grown, test.added, test.removed)
+			}
+			if w.shrunkTotal.Count != 0 {
+				t.Errorf("%d shrunk, want 0", w.shrunkTotal.Count)
+			}
+			if e := w.exts[""]; e.size != test.ext {
+				t.Errorf("extension size change %d, want %d", e.size, test.ext)
+			}
+		})
+	}
+}
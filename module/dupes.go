@@ -0,0 +1,212 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/spf13/afero"
+	"io"
+	"sort"
+)
+
+// partialHashSize defines the number of bytes from the file beginning hashed
+// to tell apart files of the same size before hashing their full content
+const partialHashSize = 4 * 1024
+
+type dupesGroup struct {
+	size  int64
+	paths []string
+}
+
+// reclaimable gets the number of bytes freed if all files but one removed
+func (g *dupesGroup) reclaimable() int64 {
+	return g.size * int64(len(g.paths)-1)
+}
+
+// dupesWorker finds files with the same content. Files are grouped by size while scanning
+// and same size files are compared by partial and then full content hash when scanning completes
+type dupesWorker struct {
+	voidInit
+	*fileFilter
+	ctx    context.Context
+	fs     afero.Fs
+	usage  Usage
+	bySize map[int64][]string
+
+	// usages keeps file size according to usage as only apparent size is used to group files
+	usages map[string]int64
+
+	groups      []*dupesGroup
+	files       int64
+	reclaimable int64
+	readErrors  int64
+
+	// stopped contains ctx error if hashing was stopped before all files compared
+	stopped error
+}
+
+type dupesRenderer struct {
+	*dupesWorker
+	top int
+}
+
+func newDupesWorker(ctx *Context, fs afero.Fs) *dupesWorker {
+	w := dupesWorker{
+		ctx:    context.Background(),
+		fs:     fs,
+		usage:  ctx.usage,
+		bySize: make(map[int64][]string),
+		usages: make(map[string]int64),
+	}
+
+	w.fileFilter = newFileFilter(w.onFile)
+
+	return &w
+}
+
+func newDupesRenderer(work *dupesWorker, top int) renderer {
+	return &dupesRenderer{dupesWorker: work, top: top}
+}
+
+// Worker methods
+
+func (m *dupesWorker) setContext(ctx context.Context) {
+	m.ctx = ctx
+}
+
+func (m *dupesWorker) onFile(f *scan.FileEntry) {
+	// Empty files and hard links to the same content are not duplicates to reclaim
+	if f.Size == 0 || f.Duplicate {
+		return
+	}
+	m.bySize[f.Size] = append(m.bySize[f.Size], f.Path)
+	m.usages[f.Path] = m.usage.size(f)
+}
+
+func (m *dupesWorker) finalize() {
+	for size, paths := range m.bySize {
+		if m.stopped != nil {
+			break
+		}
+		if len(paths) < 2 {
+			continue
+		}
+
+		for _, partial := range m.groupByHash(paths, partialHashSize) {
+			full := [][]string{partial}
+			if size > partialHashSize {
+				full = m.groupByHash(partial, size)
+			}
+
+			for _, same := range full {
+				sort.Strings(same)
+				g := dupesGroup{size: m.usages[same[0]], paths: same}
+				m.groups = append(m.groups, &g)
+				m.files += int64(len(same))
+				m.reclaimable += g.reclaimable()
+			}
+		}
+	}
+
+	sort.Slice(m.groups, func(i, j int) bool {
+		ri, rj := m.groups[i].reclaimable(), m.groups[j].reclaimable()
+		if ri == rj {
+			return m.groups[i].paths[0] < m.groups[j].paths[0]
+		}
+		return ri > rj
+	})
+
+	m.bySize = nil
+	m.usages = nil
+}
+
+// groupByHash groups files by hash of their first n bytes.
+// Only groups of two or more files are returned. Files that can't be read are skipped
+// It returns nothing if ctx is cancelled
+func (m *dupesWorker) groupByHash(paths []string, n int64) [][]string {
+	byHash := make(map[[sha256.Size]byte][]string)
+	for _, path := range paths {
+		h, err := m.hash(path, n)
+		if m.ctx.Err() != nil {
+			m.stopped = m.ctx.Err()
+			return nil
+		}
+		if err != nil {
+			m.readErrors++
+			continue
+		}
+		byHash[h] = append(byHash[h], path)
+	}
+
+	var groups [][]string
+	for _, g := range byHash {
+		if len(g) > 1 {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func (m *dupesWorker) hash(path string, n int64) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	f, err := m.fs.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer sys.Close(f)
+
+	h := sha256.New()
+	if _, err := io.CopyN(h, &ctxReader{ctx: m.ctx, r: f}, n); err != nil && err != io.EOF {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// ctxReader stops reading when ctx is cancelled so as large files hashing can be interrupted
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// Renderer method
+
+func (m *dupesRenderer) print(p printer) {
+	const format = "%v\t%v\n"
+
+	if m.stopped != nil {
+		p.cprint("<red>Hashing stopped (%v). Duplicates are incomplete</>\n", m.stopped)
+	}
+
+	p.cprint("<gray>Duplicates:</>\n\n")
+	p.print(format, "Duplicate groups:", len(m.groups))
+	p.print(format, "Files in groups:", m.files)
+	p.print(format, "Reclaimable:", human(m.reclaimable))
+	if m.readErrors > 0 {
+		p.print(format, "Files could not be read:", m.readErrors)
+	}
+	p.flush()
+
+	if len(m.groups) == 0 {
+		return
+	}
+
+	p.cprint("\n<gray>TOP %d duplicate groups by reclaimable size:</>\n\n", m.top)
+
+	for i := 0; i < m.top && i < len(m.groups); i++ {
+		g := m.groups[i]
+		p.cprint("%2d. %d files of <yellow>%s</> each, reclaimable <yellow>%s</>\n", i+1, len(g.paths), human(g.size), human(g.reclaimable()))
+		for _, path := range g.paths {
+			p.cprint("    %s\n", path)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package module
+
+import (
+	"context"
+	"github.com/spf13/afero"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newDupesFs() afero.Fs {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/a", []byte("0123456789"), 0644)
+	_ = afero.WriteFile(fs, "/b", []byte("0123456789"), 0644)
+	_ = afero.WriteFile(fs, "/c", []byte("0123456780"), 0644)
+	_ = afero.WriteFile(fs, "/d", []byte("0123456780"), 0644)
+	_ = afero.WriteFile(fs, "/e", []byte("abcdefghij"), 0644)
+	return fs
+}
+
+func TestDupesWorker_GroupByHash(t *testing.T) {
+	var tests = []struct {
+		name   string
+		paths  []string
+		n      int64
+		groups []string
+		errors int64
+	}{
+		{"full content", []string{"/a", "/b", "/c", "/d", "/e"}, 10, []string{"/a /b", "/c /d"}, 0},
+		{"partial content", []string{"/a", "/b", "/c", "/d", "/e"}, 5, []string{"/a /b /c /d"}, 0},
+		{"no same", []string{"/a", "/c", "/e"}, 10, nil, 0},
+		{"n greater than size", []string{"/a", "/b"}, 100, []string{"/a /b"}, 0},
+		{"unreadable skipped", []string{"/a", "/b", "/missing"}, 10, []string{"/a /b"}, 1},
+		{"the only readable", []string{"/a", "/missing"}, 10, nil, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newDupesWorker(NewContext(10, UsageApparent), newDupesFs())
+
+			var groups []string
+			for _, g := range w.groupByHash(test.paths, test.n) {
+				sort.Strings(g)
+				groups = append(groups, strings.Join(g, " "))
+			}
+			sort.Strings(groups)
+
+			if strings.Join(groups, ", ") != strings.Join(test.groups, ", ") {
+				t.Errorf("groups %v, want %v", groups, test.groups)
+			}
+			if w.readErrors != test.errors {
+				t.Errorf("%d read errors, want %d", w.readErrors, test.errors)
+			}
+		})
+	}
+}
+
+func TestDupesWorker_FinalizeStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	w := newDupesWorker(NewContext(10, UsageApparent), newDupesFs())
+	w.setContext(ctx)
+	w.bySize[10] = []string{"/a", "/b", "/c", "/d", "/e"}
+
+	w.finalize()
+
+	if w.stopped == nil {
+		t.Error("hashing stopped expected")
+	}
+	if len(w.groups) != 0 || w.readErrors != 0 {
+		t.Errorf("no groups and read errors expected but got %d groups and %d errors", len(w.groups), w.readErrors)
+	}
+}
@@ -0,0 +1,163 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+	"path/filepath"
+	"sort"
+)
+
+// emptyWorker finds empty files, empty folders and folders containing only empty subfolders
+type emptyWorker struct {
+	voidInit
+	files []string
+
+	// folders contains the number of files in each folder (excluding subfolders)
+	folders map[string]int64
+
+	// entries contains the number of each folder entries on disk (negative if not known).
+	// Entries that are not reported (skipped files, symlinks and others) make folder not empty
+	entries map[string]int64
+
+	// unknown contains folders which content is not known completely
+	// i.e. ones that could not be read and mount points
+	unknown []string
+
+	// emptyFolders and emptyTrees contain outermost empty folders
+	// without and with subfolders
+	emptyFolders []string
+	emptyTrees   []string
+	nested       int64
+}
+
+type emptyRenderer struct {
+	*emptyWorker
+	top int
+}
+
+func newEmptyWorker() *emptyWorker {
+	return &emptyWorker{
+		folders: make(map[string]int64),
+		entries: make(map[string]int64),
+	}
+}
+
+func newEmptyRenderer(work *emptyWorker, top int) renderer {
+	return &emptyRenderer{emptyWorker: work, top: top}
+}
+
+// Worker methods
+
+func (m *emptyWorker) handler(evt *scan.Event) {
+	switch {
+	case evt.File != nil:
+		// Archive members can't be removed separately
+		if evt.File.Size == 0 && evt.File.Archive == "" {
+			m.files = append(m.files, evt.File.Path)
+		}
+	case evt.Folder != nil:
+		// Archive folder is the archive file that is already checked
+		if evt.Folder.Archive != "" {
+			return
+		}
+		m.folders[evt.Folder.Path] += evt.Folder.Count
+		m.entries[evt.Folder.Path] = evt.Folder.Entries
+	case evt.Error != nil:
+		m.unknown = append(m.unknown, evt.Error.Path)
+	case evt.Mount != nil:
+		m.unknown = append(m.unknown, evt.Mount.Path)
+	}
+}
+
+func (m *emptyWorker) finalize() {
+	// Folders having files at any depth and their ancestors are not empty
+	notEmpty := make(map[string]bool)
+	mark := func(path string) {
+		for !notEmpty[path] {
+			notEmpty[path] = true
+			parent := filepath.Dir(path)
+			if _, ok := m.folders[parent]; !ok || parent == path {
+				return
+			}
+			path = parent
+		}
+	}
+
+	subfolders := make(map[string]int64)
+	for path := range m.folders {
+		if parent := filepath.Dir(path); parent != path {
+			subfolders[parent]++
+		}
+	}
+
+	// Folder having entries other than subfolders reported isn't empty even if
+	// they are not files reported (for example files skipped by filters or symlinks)
+	for path, count := range m.folders {
+		if entries := m.entries[path]; count > 0 || entries < 0 || entries > subfolders[path] {
+			mark(path)
+		}
+	}
+	for _, path := range m.unknown {
+		mark(path)
+	}
+
+	for path := range m.folders {
+		if notEmpty[path] {
+			continue
+		}
+		// Only the outermost empty folder is reported
+		parent := filepath.Dir(path)
+		if _, ok := m.folders[parent]; ok && parent != path && !notEmpty[parent] {
+			m.nested++
+			continue
+		}
+		if subfolders[path] > 0 {
+			m.emptyTrees = append(m.emptyTrees, path)
+		} else {
+			m.emptyFolders = append(m.emptyFolders, path)
+		}
+	}
+
+	sort.Strings(m.files)
+	sort.Strings(m.emptyFolders)
+	sort.Strings(m.emptyTrees)
+
+	m.folders = nil
+	m.entries = nil
+	m.unknown = nil
+}
+
+// Renderer method
+
+func (m *emptyRenderer) print(p printer) {
+	const format = "%v\t%v\n"
+
+	p.cprint("<gray>Empty files and folders:</>\n\n")
+
+	p.print(format, "Kind", "Count")
+	p.print(format, "----", "-----")
+	p.print(format, "Empty files", len(m.files))
+	p.print(format, "Empty folders", len(m.emptyFolders))
+	p.print(format, "Folders with only empty subfolders", len(m.emptyTrees))
+	p.print(format, "Empty subfolders within them", m.nested)
+	p.flush()
+
+	m.printPaths(p, "Empty files", m.files)
+	m.printPaths(p, "Empty folders", m.emptyFolders)
+	m.printPaths(p, "Folders with only empty subfolders", m.emptyTrees)
+}
+
+func (m *emptyRenderer) printPaths(p printer, title string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	if len(paths) > m.top {
+		p.cprint("\n<gray>%s (first %d of %d):</>\n\n", title, m.top, len(paths))
+	} else {
+		p.cprint("\n<gray>%s (%d):</>\n\n", title, len(paths))
+	}
+
+	for i := 0; i < m.top && i < len(paths); i++ {
+		p.cprint("%2d. %s\n", i+1, paths[i])
+	}
+}
@@ -0,0 +1,103 @@
+package module
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module/scan"
+	"strings"
+	"testing"
+)
+
+func folderEvent(path string, count int64, entries int64) *scan.Event {
+	return &scan.Event{Folder: &scan.FolderEntry{FileEntry: scan.FileEntry{Path: path}, Count: count, Entries: entries}}
+}
+
+func fileEvent(path string, size int64) *scan.Event {
+	return &scan.Event{File: &scan.FileEntry{Path: path, Size: size}}
+}
+
+func TestEmptyWorker_Finalize(t *testing.T) {
+	var tests = []struct {
+		name    string
+		events  []*scan.Event
+		files   []string
+		folders []string
+		trees   []string
+		nested  int64
+	}{
+		{
+			"empty folder",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/e", 0, 0)},
+			nil, []string{"/r/e"}, nil, 0,
+		},
+		{
+			"empty file",
+			[]*scan.Event{folderEvent("/r", 1, 1), fileEvent("/r/f", 0)},
+			[]string{"/r/f"}, nil, nil, 0,
+		},
+		{
+			"only empty subfolders",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/t", 0, 2), folderEvent("/r/t/a", 0, 0), folderEvent("/r/t/b", 0, 0)},
+			nil, nil, []string{"/r/t"}, 2,
+		},
+		{
+			"skipped entries",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/s", 0, 1)},
+			nil, nil, nil, 0,
+		},
+		{
+			"skipped entries deeper",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/t", 0, 1), folderEvent("/r/t/s", 0, 1)},
+			nil, nil, nil, 0,
+		},
+		{
+			"skipped entry beside empty subfolder",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/t", 0, 2), folderEvent("/r/t/e", 0, 0)},
+			nil, []string{"/r/t/e"}, nil, 0,
+		},
+		{
+			"entries not known",
+			[]*scan.Event{folderEvent("/r", 0, -1), folderEvent("/r/a", 0, -1)},
+			nil, nil, nil, 0,
+		},
+		{
+			"unreadable subfolder",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/t", 0, 1), {Error: &scan.ErrorEntry{Path: "/r/t/x", Err: errors.New("denied")}}},
+			nil, nil, nil, 0,
+		},
+		{
+			"mount point",
+			[]*scan.Event{folderEvent("/r", 1, 2), fileEvent("/r/f", 1), folderEvent("/r/t", 0, 1), {Mount: &scan.MountEntry{Path: "/r/t/m"}}},
+			nil, nil, nil, 0,
+		},
+		{
+			"archive member",
+			[]*scan.Event{folderEvent("/r", 1, 1), fileEvent("/r/a.zip", 10), {File: &scan.FileEntry{Path: "/r/a.zip/e", Archive: "/r/a.zip"}}, {Folder: &scan.FolderEntry{FileEntry: scan.FileEntry{Path: "/r/a.zip", Archive: "/r/a.zip"}, Count: 1, Entries: 1}}},
+			nil, nil, nil, 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := newEmptyWorker()
+			for _, evt := range test.events {
+				w.handler(evt)
+			}
+
+			w.finalize()
+
+			assertPaths(t, "empty files", w.files, test.files)
+			assertPaths(t, "empty folders", w.emptyFolders, test.folders)
+			assertPaths(t, "folders with only empty subfolders", w.emptyTrees, test.trees)
+			if w.nested != test.nested {
+				t.Errorf("%d nested, want %d", w.nested, test.nested)
+			}
+		})
+	}
+}
+
+func assertPaths(t *testing.T, title string, got []string, want []string) {
+	t.Helper()
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("%s %v, want %v", title, got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package module
+
+import (
+	"errors"
+	"github.com/aegoroff/dirstat/module/scan"
+	"sort"
+	"strings"
+)
+
+type readError struct {
+	path  string
+	cause error
+}
+
+type readErrors []*readError
+
+func (e readErrors) Len() int           { return len(e) }
+func (e readErrors) Less(i, j int) bool { return e[i].path < e[j].path }
+func (e readErrors) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+
+type errorsWorker struct {
+	voidInit
+	total  *totalInfo
+	errors readErrors
+
+	// files contains files that could not be read
+	files readErrors
+}
+
+type errorsRenderer struct {
+	*errorsWorker
+	top int
+}
+
+func newErrorsWorker(ctx *Context) *errorsWorker {
+	return &errorsWorker{
+		total:  ctx.total,
+		errors: make(readErrors, 0),
+		files:  make(readErrors, 0),
+	}
+}
+
+func newErrorsRenderer(work *errorsWorker, top int) renderer {
+	return &errorsRenderer{errorsWorker: work, top: top}
+}
+
+// Worker methods
+
+func (m *errorsWorker) finalize() {
+	sort.Sort(m.errors)
+	sort.Sort(m.files)
+	m.total.CountReadErrors = int64(len(m.errors))
+	m.total.CountFileErrors = int64(len(m.files))
+}
+
+func (m *errorsWorker) handler(evt *scan.Event) {
+	if evt.Error == nil {
+		return
+	}
+
+	// Path already in a message so keep just the cause
+	cause := evt.Error.Err
+	if u := errors.Unwrap(cause); u != nil {
+		cause = u
+	}
+
+	e := &readError{path: evt.Error.Path, cause: cause}
+	if evt.Error.File {
+		m.files = append(m.files, e)
+	} else {
+		m.errors = append(m.errors, e)
+	}
+}
+
+// Renderer method
+
+func (m *errorsRenderer) print(p printer) {
+	m.printErrors(p, m.errors, "Unreadable folders", "Folder")
+	m.printErrors(p, m.files, "Unreadable files", "File")
+}
+
+func (m *errorsRenderer) printErrors(p printer, errs readErrors, title string, head string) {
+	if len(errs) == 0 {
+		return
+	}
+
+	const format = "%v\t%v\n"
+
+	p.cprint("\n<gray>%s:</>\n\n", title)
+
+	p.print(format, head, "Error")
+	p.print(format, strings.Repeat("-", len(head)), "-----")
+
+	for i := 0; i < m.top && i < len(errs); i++ {
+		e := errs[i]
+		p.print(format, e.path, e.cause)
+	}
+
+	p.flush()
+
+	if len(errs) > m.top {
+		p.cprint("<gray>... and %d more</>\n", len(errs)-m.top)
+	}
+}
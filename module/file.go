@@ -1,6 +1,6 @@
 package module
 
-import "dirstat/module/internal/sys"
+import "github.com/aegoroff/dirstat/module/scan"
 
 type file struct {
 	path string
@@ -9,10 +9,13 @@ type file struct {
 
 type files []*file
 
-type fileHandler func(f *sys.FileEntry)
+type fileHandler func(f *scan.FileEntry)
 
 type fileFilter struct {
 	h fileHandler
+
+	// members set if archive members events are handled too
+	members bool
 }
 
 func (fi files) Len() int           { return len(fi) }
@@ -29,8 +32,20 @@ func newFileFilter(h fileHandler) *fileFilter {
 	}
 }
 
-func (f *fileFilter) handler(evt *sys.ScanEvent) {
-	if evt.File == nil {
+// newMembersFileFilter creates filter that passes archive members too
+// so as archives content is seen like folders one
+func newMembersFileFilter(h fileHandler) *fileFilter {
+	return &fileFilter{
+		h:       h,
+		members: true,
+	}
+}
+
+// handler calls file handler on each file event except, unless members set,
+// archive members events. Archive file itself is counted so as its members
+// must not be added to disk statistic
+func (f *fileFilter) handler(evt *scan.Event) {
+	if evt.File == nil || (evt.File.Archive != "" && !f.members) {
 		return
 	}
 	f.h(evt.File)
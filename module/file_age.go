@@ -0,0 +1,108 @@
+package module
+
+import (
+	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+// ageLimits defines files age buckets upper limits. Files older than the last limit
+// fall into one more bucket
+var ageLimits = []struct {
+	age  time.Duration
+	head string
+}{
+	{day, "Less than a day"},
+	{7 * day, "Less than a week"},
+	{30 * day, "Less than a month"},
+	{365 * day, "Less than a year"},
+}
+
+type ageWorker struct {
+	voidFinalize
+	*fileFilter
+	buckets  []fileStat
+	byAccess bool
+	ctx      *Context
+	now      time.Time
+	usage    Usage
+}
+
+type ageRenderer struct {
+	work  *ageWorker
+	total *totalInfo
+}
+
+func newAgeWorker(ctx *Context, byAccess bool) *ageWorker {
+	w := ageWorker{
+		buckets:  make([]fileStat, len(ageLimits)+1),
+		byAccess: byAccess,
+		ctx:      ctx,
+		usage:    ctx.usage,
+	}
+
+	w.fileFilter = newFileFilter(w.onFile)
+
+	return &w
+}
+
+func newAgeRenderer(ctx *Context, w *ageWorker) renderer {
+	return &ageRenderer{
+		total: ctx.total,
+		work:  w,
+	}
+}
+
+// Worker methods
+
+func (m *ageWorker) init() {
+	// The time is read here since it can be set after the module is created
+	m.now = m.ctx.now
+	if m.now.IsZero() {
+		m.now = time.Now()
+	}
+}
+
+func (m *ageWorker) onFile(f *scan.FileEntry) {
+	t := f.ModTime
+	if m.byAccess {
+		t = f.AccessTime
+	}
+	// Files from the future are considered as just modified
+	age := m.now.Sub(t)
+
+	i := 0
+	for i < len(ageLimits) && age >= ageLimits[i].age {
+		i++
+	}
+
+	m.buckets[i].TotalFilesCount++
+	m.buckets[i].TotalFilesSize += m.usage.counted(f)
+}
+
+// Renderer method
+
+func (m *ageRenderer) print(p printer) {
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+
+	if m.work.byAccess {
+		p.cprint("\n<gray>Files by access time:</>\n\n")
+	} else {
+		p.cprint("\n<gray>Files by modification time:</>\n\n")
+	}
+	p.print(format, "Age", "Amount", "%", "Size", "%")
+	p.print(format, "---", "------", "------", "----", "------")
+
+	for i, b := range m.work.buckets {
+		head := "Older than a year"
+		if i < len(ageLimits) {
+			head = ageLimits[i].head
+		}
+		head = fmt.Sprintf("%2d. %s", i+1, head)
+
+		m.total.printCountAndSizeStatLine(p, b.TotalFilesCount, b.TotalFilesSize, head)
+	}
+	p.flush()
+}
@@ -1,13 +1,16 @@
 package module
 
-import "dirstat/module/internal/sys"
+import "github.com/aegoroff/dirstat/module/scan"
 
 type aggregateFileWorker struct {
 	voidInit
 	voidFinalize
 	*fileFilter
 	aggregate  map[Range]fileStat
+	byRoot     map[Range]rootsBreakdown
+	total      *totalInfo
 	fileRanges ranges
+	usage      Usage
 }
 
 type aggregateFileRenderer struct {
@@ -15,10 +18,13 @@ type aggregateFileRenderer struct {
 	total *totalInfo
 }
 
-func newAggregateFileWorker(rs ranges) *aggregateFileWorker {
+func newAggregateFileWorker(ctx *Context, rs ranges) *aggregateFileWorker {
 	w := aggregateFileWorker{
 		aggregate:  make(map[Range]fileStat, len(rs)),
+		byRoot:     make(map[Range]rootsBreakdown, len(rs)),
+		total:      ctx.total,
 		fileRanges: rs,
+		usage:      ctx.usage,
 	}
 
 	w.fileFilter = newFileFilter(w.onFile)
@@ -33,12 +39,13 @@ func newAggregateFileRenderer(ctx *Context, w *aggregateFileWorker) *aggregateFi
 	}
 }
 
-func (m *aggregateFileWorker) onFile(f *sys.FileEntry) {
-	unsignedSize := uint64(f.Size)
+func (m *aggregateFileWorker) onFile(f *scan.FileEntry) {
+	size := m.usage.size(f)
+	unsignedSize := m.usage.counted(f)
 
 	// Calculate files range statistic
 	for _, r := range m.fileRanges {
-		if !r.Contains(f.Size) {
+		if !r.Contains(size) {
 			continue
 		}
 
@@ -46,24 +53,24 @@ func (m *aggregateFileWorker) onFile(f *sys.FileEntry) {
 		s.TotalFilesCount++
 		s.TotalFilesSize += unsignedSize
 		m.aggregate[r] = s
+
+		m.byRoot[r] = m.byRoot[r].add(m.total.root(f.Root), unsignedSize)
 	}
 }
 
 // Renderer method
 
 func (m *aggregateFileRenderer) print(p printer) {
-	const format = "%v\t%v\t%v\t%v\t%v\n"
-
 	p.cprint("<gray>Total files stat:</>\n\n")
-	p.print(format, "File size", "Amount", "%", "Size", "%")
-	p.print(format, "---------", "------", "------", "----", "------")
+	tableHeads := append([]string{"File size", "Amount", "%", "Size", "%"}, m.total.breakdownHeads()...)
+	printTableHead(p, tableHeads...)
 
 	heads := m.work.fileRanges.heads()
 	for i, r := range m.work.fileRanges {
 		count := m.work.aggregate[r].TotalFilesCount
 		sz := m.work.aggregate[r].TotalFilesSize
 
-		m.total.printCountAndSizeStatLine(p, count, sz, heads[i])
+		m.total.printCountAndSizeStatLine(p, count, sz, heads[i], m.total.breakdown(m.work.byRoot[r])...)
 	}
 	p.flush()
 }
@@ -1,7 +1,7 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
 	"sort"
 )
 
@@ -12,17 +12,19 @@ type detailFileWorker struct {
 	enabledRanges    []int
 	enabledRangesMap map[int]bool
 	fileRanges       ranges
+	usage            Usage
 }
 
 type detailFileRenderer struct {
 	*detailFileWorker
 }
 
-func newDetailFileWorker(rs ranges, enabledRanges []int) *detailFileWorker {
+func newDetailFileWorker(ctx *Context, rs ranges, enabledRanges []int) *detailFileWorker {
 	w := detailFileWorker{
 		enabledRanges: enabledRanges,
 		distribution:  make(map[Range]files, len(rs)),
 		fileRanges:    rs,
+		usage:         ctx.usage,
 	}
 
 	w.fileFilter = newFileFilter(w.onFile)
@@ -43,11 +45,13 @@ func (m *detailFileWorker) init() {
 	}
 }
 
-func (m *detailFileWorker) onFile(f *sys.FileEntry) {
+func (m *detailFileWorker) onFile(f *scan.FileEntry) {
+	size := m.usage.size(f)
+
 	// Calculate files range statistic
 	for i, r := range m.fileRanges {
 		// Store each file info within range only i verbose option set
-		if !r.Contains(f.Size) || !m.enabledRangesMap[i+1] {
+		if !r.Contains(size) || !m.enabledRangesMap[i+1] {
 			continue
 		}
 
@@ -55,7 +59,7 @@ func (m *detailFileWorker) onFile(f *sys.FileEntry) {
 		if !ok {
 			m.distribution[r] = make(files, 0)
 		}
-		fileContainer := file{size: f.Size, path: f.Path}
+		fileContainer := file{size: size, path: f.Path}
 		m.distribution[r] = append(nodes, &fileContainer)
 	}
 }
@@ -1,7 +1,7 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
 	"path/filepath"
 	"sort"
 )
@@ -10,7 +10,9 @@ type extWorker struct {
 	voidInit
 	*fileFilter
 	total      *totalInfo
+	usage      Usage
 	aggregator map[string]countSizeAggregate
+	byRoot     map[string]rootsBreakdown
 }
 
 type extRenderer struct {
@@ -21,10 +23,12 @@ type extRenderer struct {
 func newExtWorker(ctx *Context) *extWorker {
 	w := extWorker{
 		total:      ctx.total,
+		usage:      ctx.usage,
 		aggregator: make(map[string]countSizeAggregate, 8192),
+		byRoot:     make(map[string]rootsBreakdown, 8192),
 	}
 
-	w.fileFilter = newFileFilter(w.onFile)
+	w.fileFilter = newMembersFileFilter(w.onFile)
 
 	return &w
 }
@@ -39,16 +43,53 @@ func (m *extWorker) finalize() {
 	m.total.CountFileExts = len(m.aggregator)
 }
 
-func (m *extWorker) onFile(f *sys.FileEntry) {
+func (m *extWorker) handler(evt *scan.Event) {
+	// Scanned paths are registered in the order they were scanned
+	// including ones without files
+	if evt.Folder != nil {
+		m.total.root(evt.Folder.Root)
+	}
+	m.fileFilter.handler(evt)
+}
+
+func (m *extWorker) onFile(f *scan.FileEntry) {
+	sz := m.usage.counted(f)
+
+	m.aggregate(f, sz)
+
+	// Archive members are counted separately since archive file is on disk instead of them
+	if f.Archive != "" {
+		m.total.Archived.Count++
+		m.total.Archived.Size += uint64(f.Size)
+		m.total.Compressed += uint64(f.Compressed)
+		return
+	}
+
 	// Accumulate file statistic
 	m.total.FilesTotal.Count++
-	m.total.FilesTotal.Size += uint64(f.Size)
+	m.total.FilesTotal.Size += sz
 
+	if f.Ignored {
+		m.total.Ignored.Count++
+		m.total.Ignored.Size += sz
+	}
+
+	if f.Links > 1 {
+		m.total.HardLinked.Count++
+		m.total.HardLinked.Size += sz
+	}
+}
+
+// aggregate adds file to its extension statistic. Archive members are added
+// at their uncompressed or compressed size depending on usage
+func (m *extWorker) aggregate(f *scan.FileEntry, sz uint64) {
 	ext := filepath.Ext(f.Path)
 	a := m.aggregator[ext]
-	a.Size += uint64(f.Size)
+	a.Size += sz
 	a.Count++
 	m.aggregator[ext] = a
+
+	m.byRoot[ext] = m.byRoot[ext].add(m.total.root(f.Root), sz)
 }
 
 // Renderer method
@@ -65,11 +106,9 @@ func (e *extRenderer) print(p printer) {
 	sort.Sort(sort.Reverse(extBySize))
 	sort.Sort(sort.Reverse(extByCount))
 
-	const format = "%v\t%v\t%v\t%v\t%v\n"
-
 	p.cprint("\n<gray>TOP %d file extensions by size:</>\n\n", e.top)
 
-	e.printTableHead(p, format)
+	e.printTableHead(p)
 
 	e.printTopTen(p, extBySize, func(data files, item *file) (int64, uint64) {
 		count := e.work.aggregator[item.path].Count
@@ -81,7 +120,7 @@ func (e *extRenderer) print(p printer) {
 
 	p.cprint("\n<gray>TOP %d file extensions by count:</>\n\n", e.top)
 
-	e.printTableHead(p, format)
+	e.printTableHead(p)
 
 	e.printTopTen(p, extByCount, func(data files, item *file) (int64, uint64) {
 		count := item.size
@@ -92,9 +131,9 @@ func (e *extRenderer) print(p printer) {
 	p.flush()
 }
 
-func (*extRenderer) printTableHead(p printer, format string) {
-	p.print(format, "Extension", "Count", "%", "Size", "%")
-	p.print(format, "---------", "-----", "------", "----", "------")
+func (e *extRenderer) printTableHead(p printer) {
+	heads := append([]string{"Extension", "Count", "%", "Size", "%"}, e.work.total.breakdownHeads()...)
+	printTableHead(p, heads...)
 }
 
 func (e *extRenderer) printTopTen(p printer, data files, selector func(data files, item *file) (int64, uint64)) {
@@ -103,7 +142,7 @@ func (e *extRenderer) printTopTen(p printer, data files, selector func(data file
 
 		count, sz := selector(data, data[i])
 
-		e.work.total.printCountAndSizeStatLine(p, count, sz, h)
+		e.work.total.printCountAndSizeStatLine(p, count, sz, h, e.work.total.breakdown(e.work.byRoot[h])...)
 	}
 }
 
@@ -1,19 +1,19 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
 	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
 	"github.com/aegoroff/godatastruct/rbtree"
 )
 
-func newTopFilesWorker(top int) *topFilesWorker {
-	return &topFilesWorker{tree: newFixedTree(top)}
+func newTopFilesWorker(top int, usage Usage) *topFilesWorker {
+	return &topFilesWorker{tree: newFixedTree(top), usage: usage}
 }
 
 func newTopFilesRenderer(work *topFilesWorker) renderer {
 	w := topFilesRenderer{work}
 
-	w.fileFilter = newFileFilter(w.onFile)
+	w.fileFilter = newMembersFileFilter(w.onFile)
 
 	return &w
 }
@@ -22,7 +22,8 @@ type topFilesWorker struct {
 	voidInit
 	voidFinalize
 	*fileFilter
-	tree *fixedTree
+	tree  *fixedTree
+	usage Usage
 }
 
 type topFilesRenderer struct {
@@ -31,8 +32,8 @@ type topFilesRenderer struct {
 
 // Worker methods
 
-func (m *topFilesWorker) onFile(f *sys.FileEntry) {
-	fc := file{size: f.Size, path: f.Path}
+func (m *topFilesWorker) onFile(f *scan.FileEntry) {
+	fc := file{size: m.usage.size(f), path: f.Path}
 	m.tree.insert(&fc)
 }
 
@@ -1,10 +1,13 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
 	"fmt"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
 	"github.com/aegoroff/godatastruct/rbtree"
 	"github.com/akutz/sortfold"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -13,14 +16,29 @@ type folderI interface {
 	Path() string
 	Size() int64
 	Count() int64
+	Subfolders() int64
+	Root() string
 }
 
 // folder represents file system container that described by path
 // and has size and the number of elements in it (count field).
+// Total fields contain the same values but including all subfolders
 type folder struct {
-	path  string
-	size  int64
-	count int64
+	path       string
+	size       int64
+	count      int64
+	totalSize  int64
+	totalCount int64
+	subfolders int64
+
+	// depth defines the folder depth relatively to scanned path
+	depth int
+
+	// root defines scanned path the folder is within
+	root string
+
+	// archive set if the folder is an archive content
+	archive bool
 }
 
 // Count sortable folder
@@ -56,6 +74,20 @@ func (f *folder) Path() string   { return f.path }
 func (f *folder) Size() int64    { return f.size }
 func (f *folder) Count() int64   { return f.count }
 
+// Subfolders gets the number of all subfolders within folder
+func (f *folder) Subfolders() int64 { return f.subfolders }
+
+// Root gets scanned path the folder is within
+func (f *folder) Root() string { return f.root }
+
+// recursive creates folder copy which size and count include all subfolders
+func (f *folder) recursive() *folder {
+	r := *f
+	r.size = f.totalSize
+	r.count = f.totalCount
+	return &r
+}
+
 // Count sortable folder methods
 
 func (fc *folderC) LessThan(y interface{}) bool { return fc.count < y.(*folderC).count }
@@ -68,22 +100,34 @@ func (fs *folderS) EqualTo(y interface{}) bool  { return fs.size == y.(*folderS)
 
 type foldersWorker struct {
 	voidInit
-	total   *totalInfo
-	folders rbtree.RbTree
-	bySize  *fixedTree
-	byCount *fixedTree
+	total     *totalInfo
+	folders   rbtree.RbTree
+	bySize    *fixedTree
+	byCount   *fixedTree
+	recursive bool
+	usage     Usage
+
+	// archives defines the number of archive folders that are not real ones
+	archives int64
 }
 
 type foldersRenderer struct {
 	*foldersWorker
 }
 
-func newFoldersWorker(ctx *Context) *foldersWorker {
+type depthRenderer struct {
+	*foldersWorker
+	depth int
+}
+
+func newFoldersWorker(ctx *Context, recursive bool) *foldersWorker {
 	return &foldersWorker{
-		total:   ctx.total,
-		folders: rbtree.NewRbTree(),
-		bySize:  newFixedTree(ctx.top),
-		byCount: newFixedTree(ctx.top),
+		total:     ctx.total,
+		folders:   rbtree.NewRbTree(),
+		bySize:    newFixedTree(ctx.top),
+		byCount:   newFixedTree(ctx.top),
+		recursive: recursive,
+		usage:     ctx.usage,
 	}
 }
 
@@ -91,11 +135,20 @@ func newFoldersRenderer(work *foldersWorker) renderer {
 	return &foldersRenderer{work}
 }
 
+func newDepthRenderer(work *foldersWorker, depth int) renderer {
+	return &depthRenderer{foldersWorker: work, depth: depth}
+}
+
 // Worker methods
 
 func (m *foldersWorker) finalize() {
+	m.rollup()
+
 	m.folders.WalkInorder(func(node rbtree.Node) {
 		fn := node.Key().(*folder)
+		if m.recursive {
+			fn = fn.recursive()
+		}
 
 		fs := folderS{*fn}
 		m.bySize.insert(&fs)
@@ -104,23 +157,65 @@ func (m *foldersWorker) finalize() {
 		m.byCount.insert(&fc)
 	})
 
-	m.total.CountFolders = m.folders.Len()
+	m.total.CountFolders = m.folders.Len() - m.archives
 }
 
-func (m *foldersWorker) handler(evt *sys.ScanEvent) {
+func (m *foldersWorker) handler(evt *scan.Event) {
 	if evt.Folder == nil {
 		return
 	}
 	fe := evt.Folder
+	size := m.usage.size(&fe.FileEntry)
 
 	fn := folder{
-		path:  fe.Path,
-		count: fe.Count,
-		size:  fe.Size,
+		path:       fe.Path,
+		count:      fe.Count,
+		size:       size,
+		totalCount: fe.Count,
+		totalSize:  size,
+		depth:      depthOf(fe.Root, fe.Path),
+		root:       fe.Root,
+		archive:    fe.Archive != "",
+	}
+	if fn.archive {
+		m.archives++
 	}
 	m.folders.Insert(&fn)
 }
 
+// depthOf gets path depth relatively to root (root has zero depth)
+func depthOf(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// rollup adds each folder's own size and count to all its ancestors
+// so as total fields contain values including all subfolders.
+// Archive folders are not added since archive files are already counted in their folders.
+// Folder events may come in any order so it's done after scanning completes
+func (m *foldersWorker) rollup() {
+	m.folders.WalkInorder(func(node rbtree.Node) {
+		fn := node.Key().(*folder)
+		if fn.archive {
+			return
+		}
+
+		for child, path := fn.path, filepath.Dir(fn.path); path != child; child, path = path, filepath.Dir(path) {
+			n, ok := m.folders.Search(&folder{path: path})
+			if !ok {
+				break
+			}
+			parent := n.Key().(*folder)
+			parent.totalSize += fn.size
+			parent.totalCount += fn.count
+			parent.subfolders++
+		}
+	})
+}
+
 // Renderer method
 
 type folderCast func(c rbtree.Comparable) folderI
@@ -129,20 +224,26 @@ func castSize(c rbtree.Comparable) folderI  { return c.(*folderS) }
 func castCount(c rbtree.Comparable) folderI { return c.(*folderC) }
 
 func (f *foldersRenderer) print(p printer) {
-	const format = "%v\t%v\t%v\t%v\t%v\n"
+	var kind string
+	if f.recursive {
+		kind = " (including subfolders)"
+	}
 
-	p.cprint("\n<gray>TOP %d folders by size:</>\n\n", f.bySize.size)
+	p.cprint("\n<gray>TOP %d folders by size%s:</>\n\n", f.bySize.size, kind)
 
-	f.printTop(f.bySize, p, format, castSize)
+	f.printTop(f.bySize, p, castSize)
 
-	p.cprint("\n<gray>TOP %d folders by count:</>\n\n", f.byCount.size)
+	p.cprint("\n<gray>TOP %d folders by count%s:</>\n\n", f.byCount.size, kind)
 
-	f.printTop(f.byCount, p, format, castCount)
+	f.printTop(f.byCount, p, castCount)
 }
 
-func (f *foldersRenderer) printTop(ft *fixedTree, p printer, format string, cast folderCast) {
-	p.print(format, "Folder", "Files", "%", "Size", "%")
-	p.print(format, "------", "-----", "------", "----", "------")
+func (f *foldersRenderer) printTop(ft *fixedTree, p printer, cast folderCast) {
+	heads := []string{"Folder", "Files", "%", "Size", "%"}
+	if f.recursive {
+		heads = []string{"Folder", "Folders", "Files", "%", "Size", "%"}
+	}
+	printTableHead(p, append(heads, f.total.rootHead()...)...)
 
 	i := 1
 
@@ -157,11 +258,39 @@ func (f *foldersRenderer) printTop(ft *fixedTree, p printer, format string, cast
 
 func (f *foldersRenderer) printTableRow(i *int, fi folderI, p printer) {
 	h := fmt.Sprintf("%2d. %s", *i, fi.Path())
+	if f.recursive {
+		h = fmt.Sprintf("%s\t%d", h, fi.Subfolders())
+	}
 
 	*i++
 
 	count := fi.Count()
 	sz := uint64(fi.Size())
 
-	f.total.printCountAndSizeStatLine(p, count, sz, h)
+	f.total.printCountAndSizeStatLine(p, count, sz, h, f.total.rootCell(fi.Root())...)
+}
+
+// print outputs all folders ordered by size (including subfolders) ascending
+// so as the largest ones are at the bottom like du -d N | sort -h does
+func (d *depthRenderer) print(p printer) {
+	// Deeper folders are there if scanning wasn't limited by depth i.e. snapshot shown
+	all := make([]*folder, 0, d.folders.Len())
+	d.folders.WalkInorder(func(node rbtree.Node) {
+		if fo := node.Key().(*folder); fo.depth <= d.depth {
+			all = append(all, fo.recursive())
+		}
+	})
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].size < all[j].size })
+
+	p.cprint("\n<gray>Folders up to depth %d by size (including subfolders):</>\n\n", d.depth)
+
+	heads := []string{"Folder", "Files", "%", "Size", "%"}
+	printTableHead(p, append(heads, d.total.rootHead()...)...)
+
+	for _, fo := range all {
+		d.total.printCountAndSizeStatLine(p, fo.count, uint64(fo.size), fo.path, d.total.rootCell(fo.root)...)
+	}
+
+	p.flush()
 }
@@ -0,0 +1,70 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
+	"io"
+)
+
+// GraphFormat defines file system graph output format
+type GraphFormat int
+
+const (
+	// GraphDOT means Graphviz DOT format
+	GraphDOT GraphFormat = iota
+	// GraphML means GraphML format
+	GraphML
+)
+
+type graphWorker struct {
+	voidInit
+	voidFinalize
+	tree  *sys.FolderTree
+	usage Usage
+}
+
+type graphRenderer struct {
+	*graphWorker
+	top    int
+	limit  int
+	format GraphFormat
+	w      io.Writer
+}
+
+func newGraphWorker(ctx *Context) *graphWorker {
+	return &graphWorker{tree: sys.NewFolderTree(), usage: ctx.usage}
+}
+
+func newGraphRenderer(work *graphWorker, top int, limit int, format GraphFormat, w io.Writer) renderer {
+	return &graphRenderer{graphWorker: work, top: top, limit: limit, format: format, w: w}
+}
+
+// Worker method
+
+func (m *graphWorker) handler(evt *scan.Event) {
+	// Archive folders are not added since archive files are counted in their folders
+	if evt.Folder == nil || evt.Folder.Archive != "" {
+		return
+	}
+	fe := evt.Folder
+	m.tree.Add(fe.Path, m.usage.size(&fe.FileEntry), fe.Count)
+}
+
+// Renderer method
+
+func (m *graphRenderer) print(p printer) {
+	g := m.tree.Graph(m.top, m.limit)
+
+	var err error
+	if m.format == GraphML {
+		err = sys.WriteGraphML(m.w, g)
+	} else {
+		err = sys.WriteDOT(m.w, g, "dirstat")
+	}
+
+	if err != nil {
+		p.cprint("\n<red>Graph writing failed: %v</>\n", err)
+		return
+	}
+	p.cprint("\n<gray>Graph folders written:</> %d\n", g.Nodes().Len())
+}
@@ -0,0 +1,22 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+)
+
+// handlerWorker executes external handler on each event
+type handlerWorker struct {
+	voidInit
+	voidFinalize
+	h scan.Handler
+}
+
+func newHandlerWorker(h scan.Handler) *handlerWorker {
+	return &handlerWorker{h: h}
+}
+
+// Worker method
+
+func (m *handlerWorker) handler(evt *scan.Event) {
+	m.h(evt)
+}
@@ -0,0 +1,83 @@
+package sys
+
+import (
+	"bufio"
+	"github.com/spf13/afero"
+	"strconv"
+	"strings"
+)
+
+// Accounts resolves user and group names by their numeric ids
+type Accounts struct {
+	users  map[uint32]string
+	groups map[uint32]string
+}
+
+// ReadAccounts reads user names from /etc/passwd and group names from /etc/group.
+// Missing or unreadable files are treated as empty so names fall back to ids
+func ReadAccounts(fs afero.Fs) *Accounts {
+	return &Accounts{
+		users:  readIDNames(fs, "/etc/passwd"),
+		groups: readIDNames(fs, "/etc/group"),
+	}
+}
+
+// User gets user name by uid or uid itself if the name is unknown
+func (a *Accounts) User(uid uint32) string {
+	return nameOrID(a.users, uid)
+}
+
+// Group gets group name by gid or gid itself if the name is unknown
+func (a *Accounts) Group(gid uint32) string {
+	return nameOrID(a.groups, gid)
+}
+
+// Orphan gets whether there is no user with uid specified.
+// It's always false if no users were read (for example there is no /etc/passwd)
+func (a *Accounts) Orphan(uid uint32) bool {
+	if len(a.users) == 0 {
+		return false
+	}
+	_, ok := a.users[uid]
+	return !ok
+}
+
+func nameOrID(names map[uint32]string, id uint32) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// readIDNames reads colon separated file where the first field is a name
+// and the third one is a numeric id like /etc/passwd and /etc/group are.
+// The first name of an id is kept
+func readIDNames(fs afero.Fs, path string) map[uint32]string {
+	names := make(map[uint32]string)
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return names
+	}
+	defer Close(f)
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+	return names
+}
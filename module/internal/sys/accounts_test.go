@@ -0,0 +1,76 @@
+package sys
+
+import (
+	"github.com/spf13/afero"
+	"testing"
+)
+
+func TestReadIDNames(t *testing.T) {
+	var tests = []struct {
+		name    string
+		content string
+		names   map[uint32]string
+	}{
+		{"passwd", "root:x:0:0:root:/root:/bin/bash\nuser:x:1000:1000::/home/user:/bin/sh\n", map[uint32]string{0: "root", 1000: "user"}},
+		{"group", "root:x:0:\nwheel:x:10:user\n", map[uint32]string{0: "root", 10: "wheel"}},
+		{"comments and empty lines", "# comment\n\nroot:x:0:0\n", map[uint32]string{0: "root"}},
+		{"malformed lines", "short:x\nbad:x:id:0\nneg:x:-1:0\nbig:x:4294967296:0\nok:x:1:1\n", map[uint32]string{1: "ok"}},
+		{"the first name of id kept", "first:x:5:5\nsecond:x:5:5\n", map[uint32]string{5: "first"}},
+		{"windows line endings", "root:x:0:0\r\n", map[uint32]string{0: "root"}},
+		{"empty", "", map[uint32]string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			_ = afero.WriteFile(fs, "/etc/passwd", []byte(test.content), 0644)
+
+			names := readIDNames(fs, "/etc/passwd")
+
+			if len(names) != len(test.names) {
+				t.Errorf("names %v, want %v", names, test.names)
+			}
+			for id, name := range test.names {
+				if names[id] != name {
+					t.Errorf("names %v, want %v", names, test.names)
+				}
+			}
+		})
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/etc/passwd", []byte("root:x:0:0\n"), 0644)
+	_ = afero.WriteFile(fs, "/etc/group", []byte("wheel:x:10:\n"), 0644)
+
+	var tests = []struct {
+		name   string
+		fs     afero.Fs
+		id     uint32
+		user   string
+		group  string
+		orphan bool
+	}{
+		{"known user", fs, 0, "root", "0", false},
+		{"known group", fs, 10, "10", "wheel", true},
+		{"unknown", fs, 1000, "1000", "1000", true},
+		{"no accounts files", afero.NewMemMapFs(), 1000, "1000", "1000", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := ReadAccounts(test.fs)
+
+			if a.User(test.id) != test.user {
+				t.Errorf("User(%d) = %q, want %q", test.id, a.User(test.id), test.user)
+			}
+			if a.Group(test.id) != test.group {
+				t.Errorf("Group(%d) = %q, want %q", test.id, a.Group(test.id), test.group)
+			}
+			if a.Orphan(test.id) != test.orphan {
+				t.Errorf("Orphan(%d) = %v, want %v", test.id, a.Orphan(test.id), test.orphan)
+			}
+		})
+	}
+}
@@ -1,30 +1,36 @@
 package sys
 
 import (
+	"container/heap"
+	"encoding/xml"
 	"fmt"
-	"github.com/spf13/afero"
+	"github.com/dustin/go-humanize"
+	"gonum.org/v1/gonum/graph"
+	"gonum.org/v1/gonum/graph/encoding"
+	"gonum.org/v1/gonum/graph/encoding/dot"
+	"gonum.org/v1/gonum/graph/simple"
+	"io"
 	"math"
 	"path/filepath"
-	"time"
-
-	"gonum.org/v1/gonum/graph/simple"
+	"sort"
+	"strconv"
 )
 
+// node is a folder in file system graph. Pruned node stands for several folders
+// that were left out of the graph
 type node struct {
 	NodeID int64
 	Name   string
-	IsDir  bool
+	Path   string
+	Size   int64
+	Count  int64
+	Pruned int
 }
 
-type dirNode struct {
-	Node *node
-	Path string
-}
-
-type walkNode struct {
-	Node   *node
-	Parent string
-	Size   int64
+// edge connects folder with its subfolder. Its weight is subfolder size
+type edge struct {
+	simple.WeightedEdge
+	total int64
 }
 
 func (n node) ID() int64 {
@@ -32,62 +38,326 @@ func (n node) ID() int64 {
 }
 
 func (n node) DOTID() string {
-	return fmt.Sprintf("\"%s\"", n.Name)
+	return strconv.Quote(n.Path)
+}
+
+func (n node) Attributes() []encoding.Attribute {
+	label := fmt.Sprintf("%s\n%s", n.Name, humanize.IBytes(uint64(n.Size)))
+	attrs := []encoding.Attribute{{Key: "label", Value: label}}
+	if n.Pruned > 0 {
+		attrs = append(attrs, encoding.Attribute{Key: "style", Value: "dashed"})
+	}
+	return attrs
+}
+
+func (e edge) Attributes() []encoding.Attribute {
+	// Pen width from 1 to 5 depending on the share of all bytes
+	width := 1.0
+	if e.total > 0 {
+		width += 4 * e.Weight() / float64(e.total)
+	}
+	return []encoding.Attribute{
+		{Key: "label", Value: humanize.IBytes(uint64(e.Weight()))},
+		{Key: "penwidth", Value: strconv.FormatFloat(width, 'f', 2, 64)},
+	}
 }
 
-func createFileSystemGraph(path string, fs afero.Fs) (graph *simple.WeightedDirectedGraph, root *node, elapsed time.Duration) {
-	graph = simple.NewWeightedDirectedGraph(0, math.Inf(1))
+// treeFolder is folder with its size and count including all subfolders
+type treeFolder struct {
+	path     string
+	size     int64
+	count    int64
+	children []*treeFolder
+}
 
-	start := time.Now()
+// FolderTree accumulates folders to build file system graph. It's not safe for concurrent use
+type FolderTree struct {
+	folders map[string]*treeFolder
+}
 
-	root = &node{NodeID: 0, Name: path, IsDir: true}
-	graph.AddNode(root)
+// NewFolderTree creates new empty FolderTree
+func NewFolderTree() *FolderTree {
+	return &FolderTree{folders: make(map[string]*treeFolder)}
+}
 
-	ch := make(chan *walkNode, 1024)
+// Add adds folder with size and count of files within it (excluding subfolders)
+func (t *FolderTree) Add(path string, size int64, count int64) {
+	f, ok := t.folders[path]
+	if !ok {
+		f = &treeFolder{path: path}
+		t.folders[path] = f
+	}
+	f.size += size
+	f.count += count
+}
 
-	go runWalkingDir(path, fs, 1, ch)
+// Graph creates graph of all folders added. Each folder is connected with its subfolders
+// by edges weighted by subfolder size including all its content. Only top largest
+// subfolders of each folder are in the graph and no more than limit folders overall
+// (scanned paths are always in the graph). Folders are added from the largest one
+// so as the graph contains the largest folders whatever depth they are at.
+// Subfolders left out are replaced by one pruned node per folder.
+// Zero top or limit means no such pruning
+func (t *FolderTree) Graph(top int, limit int) *simple.WeightedDirectedGraph {
+	roots := t.link()
 
-	queue := []*dirNode{{Node: root, Path: path}}
+	g := simple.NewWeightedDirectedGraph(0, math.Inf(1))
 
-	for walkNode := range ch {
-		node := walkNode.Node
+	var total int64
+	for _, r := range roots {
+		total += r.size
+	}
+
+	var nextID int64
+	newNode := func(n *node) *node {
+		n.NodeID = nextID
+		nextID++
+		g.AddNode(n)
+		return n
+	}
 
-		graph.AddNode(node)
+	// pruned contains folders left out of the graph by parent node
+	pruned := make(map[*node][]*treeFolder)
+	var parents []*node
 
-		for queue[0].Path != walkNode.Parent {
-			queue = queue[1:]
+	prune := func(parent *node, folders ...*treeFolder) {
+		if len(folders) == 0 {
+			return
 		}
+		if _, ok := pruned[parent]; !ok {
+			parents = append(parents, parent)
+		}
+		pruned[parent] = append(pruned[parent], folders...)
+	}
 
-		parentNode := queue[0]
+	// candidates contains folders which parents are in the graph. The largest one is added next
+	candidates := &graphCandidates{}
+	addChildren := func(f *treeFolder, n *node) {
+		children := f.children
+		if top > 0 && len(children) > top {
+			prune(n, children[top:]...)
+			children = children[:top]
+		}
+		for _, c := range children {
+			heap.Push(candidates, graphCandidate{folder: c, parent: n})
+		}
+	}
 
-		if node.IsDir {
-			fullPath := filepath.Join(walkNode.Parent, node.Name)
-			queue = append(queue, &dirNode{Node: node, Path: fullPath})
+	for _, r := range roots {
+		n := newNode(&node{Name: r.path, Path: r.path, Size: r.size, Count: r.count})
+		addChildren(r, n)
+	}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(graphCandidate)
+		if limit > 0 && g.Nodes().Len() >= limit {
+			prune(c.parent, c.folder)
+			continue
 		}
 
-		weight := float64(walkNode.Size)
-		edge := graph.NewWeightedEdge(parentNode.Node, node, weight)
-		graph.SetWeightedEdge(edge)
+		n := newNode(&node{Name: filepath.Base(c.folder.path), Path: c.folder.path, Size: c.folder.size, Count: c.folder.count})
+		g.SetWeightedEdge(newEdge(c.parent, n, total))
+		addChildren(c.folder, n)
+	}
+
+	for _, parent := range parents {
+		folders := pruned[parent]
+		n := &node{Pruned: len(folders), Path: parent.Path + string(filepath.Separator) + "..."}
+		for _, p := range folders {
+			n.Size += p.size
+			n.Count += p.count
+		}
+		n.Name = fmt.Sprintf("%d more folders", n.Pruned)
+		if n.Pruned == 1 {
+			n.Name = "1 more folder"
+		}
+		newNode(n)
+		g.SetWeightedEdge(newEdge(parent, n, total))
 	}
 
-	elapsed = time.Since(start)
-	return graph, root, elapsed
+	return g
 }
 
-func runWalkingDir(path string, fs afero.Fs, nextID int64, ch chan<- *walkNode) {
-	filesystemCh := make(chan *filesystemItem, 1024)
-	go func() {
-		walkDirBreadthFirst(path, fs, filesystemCh)
-	}()
+// graphCandidate is folder which parent is in the graph already
+type graphCandidate struct {
+	folder *treeFolder
+	parent *node
+}
+
+// graphCandidates is max heap of folders by size
+type graphCandidates []graphCandidate
+
+func (h graphCandidates) Len() int { return len(h) }
+
+func (h graphCandidates) Less(i, j int) bool {
+	if h[i].folder.size == h[j].folder.size {
+		return h[i].folder.path < h[j].folder.path
+	}
+	return h[i].folder.size > h[j].folder.size
+}
+
+func (h graphCandidates) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *graphCandidates) Push(x interface{}) { *h = append(*h, x.(graphCandidate)) }
+
+func (h *graphCandidates) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
 
-	go func() {
-		defer close(ch)
-		for item := range filesystemCh {
-			if item.event != fsEventDir {
-				node := &node{NodeID: nextID, Name: item.name, IsDir: item.count > 1}
-				ch <- &walkNode{Node: node, Parent: item.dir, Size: item.size}
-				nextID++
-			}
+// link connects folders with their parents and calculates sizes including subfolders.
+// It returns folders without parents ordered by size
+func (t *FolderTree) link() []*treeFolder {
+	var roots []*treeFolder
+	for path, f := range t.folders {
+		parent, ok := t.folders[filepath.Dir(path)]
+		if !ok || parent == f {
+			roots = append(roots, f)
+			continue
 		}
-	}()
+		parent.children = append(parent.children, f)
+	}
+
+	var total func(f *treeFolder)
+	total = func(f *treeFolder) {
+		for _, c := range f.children {
+			total(c)
+			f.size += c.size
+			f.count += c.count
+		}
+		sortBySize(f.children)
+	}
+
+	for _, r := range roots {
+		total(r)
+	}
+	sortBySize(roots)
+
+	return roots
+}
+
+func sortBySize(folders []*treeFolder) {
+	sort.Slice(folders, func(i, j int) bool {
+		if folders[i].size == folders[j].size {
+			return folders[i].path < folders[j].path
+		}
+		return folders[i].size > folders[j].size
+	})
+}
+
+func newEdge(from *node, to *node, total int64) graph.WeightedEdge {
+	return edge{
+		WeightedEdge: simple.WeightedEdge{F: from, T: to, W: float64(to.Size)},
+		total:        total,
+	}
+}
+
+// WriteDOT writes graph in Graphviz DOT format
+func WriteDOT(w io.Writer, g *simple.WeightedDirectedGraph, name string) error {
+	b, err := dot.Marshal(g, name, "", "  ")
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(b); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes graph in GraphML format
+func WriteGraphML(w io.Writer, g *simple.WeightedDirectedGraph) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "name", For: "node", Name: "name", Type: "string"},
+			{ID: "path", For: "node", Name: "path", Type: "string"},
+			{ID: "size", For: "node", Name: "size", Type: "long"},
+			{ID: "files", For: "node", Name: "files", Type: "long"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "long"},
+		},
+		Graph: graphMLGraph{ID: "G", EdgeDefault: "directed"},
+	}
+
+	nodes := graph.NodesOf(g.Nodes())
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+
+	nodeID := func(n graph.Node) string { return "n" + strconv.FormatInt(n.ID(), 10) }
+
+	for _, gn := range nodes {
+		n := gn.(*node)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: nodeID(n),
+			Data: []graphMLData{
+				{Key: "name", Value: n.Name},
+				{Key: "path", Value: n.Path},
+				{Key: "size", Value: strconv.FormatInt(n.Size, 10)},
+				{Key: "files", Value: strconv.FormatInt(n.Count, 10)},
+			},
+		})
+	}
+
+	for _, from := range nodes {
+		to := graph.NodesOf(g.From(from.ID()))
+		sort.Slice(to, func(i, j int) bool { return to[i].ID() < to[j].ID() })
+		for _, t := range to {
+			weight, _ := g.Weight(from.ID(), t.ID())
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+				Source: nodeID(from),
+				Target: nodeID(t),
+				Data:   []graphMLData{{Key: "weight", Value: strconv.FormatInt(int64(weight), 10)}},
+			})
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
 }
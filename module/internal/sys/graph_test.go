@@ -0,0 +1,98 @@
+package sys
+
+import (
+	"sort"
+	"testing"
+)
+
+func newTestFolderTree() *FolderTree {
+	t := NewFolderTree()
+	t.Add("/r", 1, 1)
+	t.Add("/r/a", 100, 1)
+	t.Add("/r/a/a1", 50, 1)
+	t.Add("/r/a/a2", 40, 1)
+	t.Add("/r/a/a1/deep", 45, 1)
+	t.Add("/r/b", 30, 1)
+	t.Add("/r/c", 20, 1)
+	t.Add("/r/d", 10, 1)
+	return t
+}
+
+func TestFolderTree_Graph(t *testing.T) {
+	var tests = []struct {
+		name    string
+		top     int
+		limit   int
+		folders []string
+		pruned  map[string]int
+	}{
+		{
+			"no pruning", 0, 0,
+			[]string{"/r", "/r/a", "/r/a/a1", "/r/a/a1/deep", "/r/a/a2", "/r/b", "/r/c", "/r/d"},
+			map[string]int{},
+		},
+		{
+			"top of each folder", 2, 0,
+			[]string{"/r", "/r/a", "/r/a/a1", "/r/a/a1/deep", "/r/a/a2", "/r/b"},
+			map[string]int{"/r/...": 2},
+		},
+		{
+			"the largest folders at any depth", 0, 4,
+			[]string{"/r", "/r/a", "/r/a/a1", "/r/a/a1/deep"},
+			map[string]int{"/r/...": 3, "/r/a/...": 1},
+		},
+		{
+			"top and limit", 1, 3,
+			[]string{"/r", "/r/a", "/r/a/a1"},
+			map[string]int{"/r/...": 3, "/r/a/...": 1, "/r/a/a1/...": 1},
+		},
+		{
+			"root is always there", 0, 1,
+			[]string{"/r"},
+			map[string]int{"/r/...": 4},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := newTestFolderTree().Graph(test.top, test.limit)
+
+			var folders []string
+			pruned := make(map[string]int)
+			nodes := g.Nodes()
+			for nodes.Next() {
+				n := nodes.Node().(*node)
+				if n.Pruned > 0 {
+					pruned[n.Path] = n.Pruned
+				} else {
+					folders = append(folders, n.Path)
+				}
+			}
+			sort.Strings(folders)
+
+			if !equalStrings(folders, test.folders) {
+				t.Errorf("folders %v, want %v", folders, test.folders)
+			}
+			if len(pruned) != len(test.pruned) {
+				t.Errorf("pruned %v, want %v", pruned, test.pruned)
+			}
+			for path, count := range test.pruned {
+				if pruned[path] != count {
+					t.Errorf("pruned %v, want %v", pruned, test.pruned)
+				}
+			}
+		})
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
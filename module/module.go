@@ -1,30 +1,90 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/gookit/color"
 	"github.com/spf13/afero"
 	"io"
+	"time"
 )
 
 // Context defines modules context
 type Context struct {
 	total *totalInfo
 	top   int
+	usage Usage
+
+	// now defines the time files age is measured from. Zero means the time scanning starts
+	now time.Time
 }
 
-// NewContext creates new module's context that needed to create new modules
-func NewContext(top int) *Context {
+// NewContext creates new module's context that needed to create new modules.
+// usage defines which file size all modules use
+func NewContext(top int, usage Usage) *Context {
 	total := totalInfo{}
 
 	ctx := Context{
 		total: &total,
 		top:   top,
+		usage: usage,
 	}
 	return &ctx
 }
 
-// Execute runs modules over path specified
-func Execute(path string, fs afero.Fs, w io.Writer, modules ...Module) {
+// SetNow sets the time files age is measured from (for example the time snapshot shown was taken).
+// By default it's the time scanning starts
+func (c *Context) SetNow(now time.Time) {
+	c.now = now
+}
+
+// Source defines scanning events source i.e. paths scanned, files list or snapshot.
+// It executes handlers on each event and returns reading failure if any
+// or ctx error if reading was interrupted before all events were handled
+type Source func(ctx context.Context, handlers []scan.Handler) error
+
+// NewPathsSource creates Source that scans paths specified one by one
+func NewPathsSource(paths []string, fs afero.Fs, opt scan.Options) Source {
+	return func(ctx context.Context, handlers []scan.Handler) error {
+		return scan.Scan(ctx, paths, fs, opt, handlers...)
+	}
+}
+
+// NewListSource creates Source that scans files listed in list instead of walking folders.
+// Paths in list are separated by sep. name defines the list name (for example file path or stdin)
+// that is used as scanned path
+func NewListSource(name string, list io.Reader, sep byte, fs afero.Fs, opt scan.Options) Source {
+	return func(ctx context.Context, handlers []scan.Handler) error {
+		return scan.ScanList(ctx, name, list, sep, fs, opt, handlers...)
+	}
+}
+
+// NewSnapshotSource creates Source that reads events from snapshot instead of scanning.
+// Scanning options were applied when the snapshot was taken. Source fails
+// after all events read if the snapshot was taken by interrupted scanning
+func NewSnapshotSource(snapshot *scan.SnapshotReader) Source {
+	return func(ctx context.Context, handlers []scan.Handler) error {
+		err := snapshot.Scan(ctx, handlers...)
+		if err != nil && !interrupted(err) && !errors.Is(err, scan.ErrIncompleteSnapshot) {
+			return fmt.Errorf("snapshot reading failed: %w", err)
+		}
+		return err
+	}
+}
+
+// Execute runs modules over paths specified. Paths are scanned one by one and modules
+// render combined results. If ctx is cancelled before scanning completes
+// modules render results collected so far and the output is marked as incomplete
+func Execute(ctx context.Context, paths []string, fs afero.Fs, w io.Writer, opt scan.Options, modules ...Module) {
+	ExecuteSource(ctx, NewPathsSource(paths, fs, opt), w, modules...)
+}
+
+// ExecuteSource runs modules over events that source produces. If ctx is cancelled
+// or source fails before all events read modules render results collected so far
+// and the output is marked as incomplete
+func ExecuteSource(ctx context.Context, source Source, w io.Writer, modules ...Module) {
 	var renderers []renderer
 	var workers []worker
 
@@ -33,24 +93,73 @@ func Execute(path string, fs afero.Fs, w io.Writer, modules ...Module) {
 		workers = append(workers, m.workers()...)
 	}
 
-	var handlers []sys.ScanHandler
+	var handlers []scan.Handler
 	for _, wo := range workers {
+		if c, ok := wo.(contextSetter); ok {
+			c.setContext(ctx)
+		}
 		wo.init()
 		handlers = append(handlers, wo.handler)
 	}
 
-	sys.Scan(path, fs, handlers)
+	err := source(ctx, handlers)
 
 	for _, wo := range workers {
+		if c, ok := wo.(scanCompleter); ok {
+			c.completed(err)
+		}
 		wo.finalize()
 	}
 
+	printIncomplete(w, err)
+
 	render(w, renderers)
 }
 
-// NewFoldersModule creates new folders module
-func NewFoldersModule(ctx *Context, hideOutput bool) Module {
-	work := newFoldersWorker(ctx)
+// ExecuteDiff compares base state with current state (for example snapshot taken earlier
+// with paths scanned now) and renders added, removed, grown and shrunk files,
+// folders size changes and file extensions size changes. Files and folders are matched by path.
+// Partial state makes any difference meaningless so nothing is rendered and the error is returned
+// if either source fails or ctx is cancelled before both states read
+func ExecuteDiff(ctx context.Context, base Source, current Source, w io.Writer, c *Context) error {
+	work := newDiffWorker(c)
+
+	work.base = true
+	if err := base(ctx, []scan.Handler{work.handler}); err != nil {
+		return err
+	}
+
+	work.base = false
+	if err := current(ctx, []scan.Handler{work.handler}); err != nil {
+		return err
+	}
+
+	work.finalize()
+
+	render(w, []renderer{newDiffRenderer(work, c.top)})
+
+	return nil
+}
+
+// printIncomplete marks the output as incomplete if scanning was interrupted or reading failed
+func printIncomplete(w io.Writer, err error) {
+	switch {
+	case interrupted(err):
+		color.Fprintf(w, "<red>Scanning stopped (%v). Results are incomplete</>\n", err)
+	case err != nil:
+		color.Fprintf(w, "<red>%v. Results are incomplete</>\n", err)
+	}
+}
+
+// interrupted gets whether err means that scanning was cancelled or timed out
+func interrupted(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// NewFoldersModule creates new folders module.
+// If recursive set folders ranked by size and count including all subfolders
+func NewFoldersModule(ctx *Context, hideOutput bool, recursive bool) Module {
+	work := newFoldersWorker(ctx, recursive)
 	if hideOutput {
 		return newModule(work)
 	}
@@ -58,16 +167,36 @@ func NewFoldersModule(ctx *Context, hideOutput bool) Module {
 	return newModule(work, rend)
 }
 
+// NewDepthModule creates new module that shows all folders up to the depth specified
+// with their size and count including subfolders. Folders collected by folders module
+// (created by NewFoldersModule) are shown so as they are not collected twice.
+// It does nothing if depth isn't positive
+func NewDepthModule(folders Module, depth int) Module {
+	m := module{
+		[]worker{},
+		[]renderer{},
+	}
+	if depth <= 0 {
+		return &m
+	}
+	for _, w := range folders.workers() {
+		if work, ok := w.(*foldersWorker); ok {
+			m.rnd = append(m.rnd, newDepthRenderer(work, depth))
+		}
+	}
+	return &m
+}
+
 // NewTopFilesModule creates new top files statistic module
 func NewTopFilesModule(ctx *Context) Module {
-	work := newTopFilesWorker(ctx.top)
+	work := newTopFilesWorker(ctx.top, ctx.usage)
 	rend := newTopFilesRenderer(work)
 	m := newModule(work, rend)
 	return m
 }
 
 // NewDetailFileModule creates new file statistic by file size range module
-func NewDetailFileModule(enabledRanges []int) Module {
+func NewDetailFileModule(ctx *Context, enabledRanges []int) Module {
 	// Do nothing if verbose not enabled
 	if len(enabledRanges) == 0 {
 		return &module{
@@ -75,7 +204,7 @@ func NewDetailFileModule(enabledRanges []int) Module {
 			[]renderer{},
 		}
 	}
-	work := newDetailFileWorker(newRanges(), enabledRanges)
+	work := newDetailFileWorker(ctx, newRanges(), enabledRanges)
 	rend := newDetailFileRenderer(work)
 	m := newModule(work, rend)
 	return m
@@ -93,13 +222,117 @@ func NewExtensionModule(ctx *Context, hideOutput bool) Module {
 
 // NewAggregateFileModule creates new total file statistic module
 func NewAggregateFileModule(ctx *Context) Module {
-	work := newAggregateFileWorker(newRanges())
+	work := newAggregateFileWorker(ctx, newRanges())
 	rend := newAggregateFileRenderer(ctx, work)
 
 	m := newModule(work, rend)
 	return m
 }
 
+// NewAgeModule creates new module that shows files statistic by age i.e. time passed
+// since the last modification or, if byAccess set, since the last access
+func NewAgeModule(ctx *Context, byAccess bool) Module {
+	work := newAgeWorker(ctx, byAccess)
+	rend := newAgeRenderer(ctx, work)
+	return newModule(work, rend)
+}
+
+// NewDupesModule creates new module that finds files with the same content.
+// Same size files are read from fs when scanning completes to compare their content hashes
+// so as files must be there i.e. it can't be used with snapshot source. Hashing stops
+// when scanning ctx is cancelled and results are marked as incomplete.
+// Duplicate groups are ranked by the number of bytes freed if all files but one removed
+func NewDupesModule(ctx *Context, fs afero.Fs) Module {
+	work := newDupesWorker(ctx, fs)
+	rend := newDupesRenderer(work, ctx.top)
+	return newModule(work, rend)
+}
+
+// NewOwnersModule creates new module that shows files statistic by owner user and group.
+// Names are read from /etc/passwd and /etc/group on fs. Unknown ones are shown as numeric ids
+func NewOwnersModule(ctx *Context, fs afero.Fs) Module {
+	work := newOwnersWorker(ctx)
+	rend := newOwnersRenderer(ctx, work, fs)
+	return newModule(work, rend)
+}
+
+// AuditModule is the module that finds files and folders with risky permissions or owners
+type AuditModule interface {
+	Module
+
+	// Findings gets the number of files and folders found. It's available after execution
+	Findings() int64
+}
+
+// NewAuditModule creates new module that finds world-writable files and folders,
+// setuid and setgid files, files and folders which owner users don't exist anymore
+// (according to /etc/passwd on fs) and group-writable files in home folders.
+// Folders content must not be rolled up (by MaxDepth option) so as subfolders are checked too
+func NewAuditModule(ctx *Context, fs afero.Fs) AuditModule {
+	work := newAuditWorker(ctx, fs)
+	rend := newAuditRenderer(work, ctx.top)
+	return &auditModule{Module: newModule(work, rend), work: work}
+}
+
+// NewEmptyModule creates new module that shows empty files, empty folders and
+// folders containing only empty subfolders. Folders that could not be read completely
+// or contain anything not reported (for example files skipped by filters or symlinks)
+// are not considered empty. Only the outermost empty folder of nested ones is shown
+func NewEmptyModule(ctx *Context) Module {
+	work := newEmptyWorker()
+	rend := newEmptyRenderer(work, ctx.top)
+	return newModule(work, rend)
+}
+
+// NewErrorsModule creates new module that collects folders which could not be read
+func NewErrorsModule(ctx *Context, hideOutput bool) Module {
+	work := newErrorsWorker(ctx)
+	if hideOutput {
+		return newModule(work)
+	}
+	rend := newErrorsRenderer(work, ctx.top)
+	return newModule(work, rend)
+}
+
+// NewMountsModule creates new module that shows mount points skipped while scanning
+func NewMountsModule() Module {
+	work := newMountsWorker()
+	rend := newMountsRenderer(work)
+	return newModule(work, rend)
+}
+
+// NewRootsModule creates new module that shows files statistic of each scanned path.
+// It shows nothing if only one path scanned
+func NewRootsModule(ctx *Context) Module {
+	work := newRootsWorker(ctx)
+	rend := newRootsRenderer(work)
+	return newModule(work, rend)
+}
+
+// NewSnapshotModule creates new module that writes all scanning events into snapshot.
+// The writer must be closed after execution
+func NewSnapshotModule(writer *scan.SnapshotWriter) Module {
+	work := newSnapshotWorker(writer)
+	rend := newSnapshotRenderer(work)
+	return newModule(work, rend)
+}
+
+// NewGraphModule creates new module that writes folders graph into w in the format specified.
+// Edges are weighted by subfolder size and only ctx top largest subfolders of each folder
+// and no more than limit largest folders overall are in the graph. Zero limit means no limit
+func NewGraphModule(ctx *Context, w io.Writer, format GraphFormat, limit int) Module {
+	work := newGraphWorker(ctx)
+	rend := newGraphRenderer(work, ctx.top, limit, format, w)
+	return newModule(work, rend)
+}
+
+// NewHandlerModule creates new module that executes handler on each scanning event
+// the same way other modules are. It has no output. Handler is called sequentially
+// (never concurrently) with other modules handlers
+func NewHandlerModule(handler scan.Handler) Module {
+	return newModule(newHandlerWorker(handler))
+}
+
 // NewTotalModule creates new total statistic module
 func NewTotalModule(ctx *Context) Module {
 	rend := newTotalRenderer(ctx)
@@ -1,7 +1,8 @@
 package module
 
 import (
-	"dirstat/module/internal/sys"
+	"context"
+	"github.com/aegoroff/dirstat/module/scan"
 )
 
 // Module defines working modules interface
@@ -17,7 +18,7 @@ type worker interface {
 }
 
 type handlerer interface {
-	handler(evt *sys.ScanEvent)
+	handler(evt *scan.Event)
 }
 
 type initer interface {
@@ -28,6 +29,19 @@ type finalizer interface {
 	finalize()
 }
 
+// contextSetter is implemented by workers that do long work in finalize
+// so as it's stopped the same way scanning is
+type contextSetter interface {
+	setContext(ctx context.Context)
+}
+
+// scanCompleter is implemented by workers that need to know
+// whether all events were read before finalize is called
+type scanCompleter interface {
+	// completed receives source failure or ctx error if reading was interrupted
+	completed(err error)
+}
+
 type renderer interface {
 	print(p printer)
 }
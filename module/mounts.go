@@ -0,0 +1,50 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+	"sort"
+)
+
+type mountsWorker struct {
+	voidInit
+	mounts []string
+}
+
+type mountsRenderer struct {
+	*mountsWorker
+}
+
+func newMountsWorker() *mountsWorker {
+	return &mountsWorker{mounts: make([]string, 0)}
+}
+
+func newMountsRenderer(work *mountsWorker) renderer {
+	return &mountsRenderer{work}
+}
+
+// Worker methods
+
+func (m *mountsWorker) finalize() {
+	sort.Strings(m.mounts)
+}
+
+func (m *mountsWorker) handler(evt *scan.Event) {
+	if evt.Mount == nil {
+		return
+	}
+	m.mounts = append(m.mounts, evt.Mount.Path)
+}
+
+// Renderer method
+
+func (m *mountsRenderer) print(p printer) {
+	if len(m.mounts) == 0 {
+		return
+	}
+
+	p.cprint("\n<gray>Skipped mount points (%d):</>\n\n", len(m.mounts))
+
+	for _, path := range m.mounts {
+		p.cprint("   %s\n", path)
+	}
+}
@@ -0,0 +1,121 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/spf13/afero"
+	"sort"
+)
+
+type ownersWorker struct {
+	voidInit
+	voidFinalize
+	*fileFilter
+	usage  Usage
+	users  map[uint32]countSizeAggregate
+	groups map[uint32]countSizeAggregate
+
+	// unknown contains files which owner isn't available
+	unknown countSizeAggregate
+}
+
+// ownerRow defines owner or group table row
+type ownerRow struct {
+	name string
+	countSizeAggregate
+}
+
+type ownersRenderer struct {
+	work     *ownersWorker
+	total    *totalInfo
+	accounts *sys.Accounts
+	top      int
+}
+
+func newOwnersWorker(ctx *Context) *ownersWorker {
+	w := ownersWorker{
+		usage:  ctx.usage,
+		users:  make(map[uint32]countSizeAggregate),
+		groups: make(map[uint32]countSizeAggregate),
+	}
+
+	w.fileFilter = newFileFilter(w.onFile)
+
+	return &w
+}
+
+func newOwnersRenderer(ctx *Context, work *ownersWorker, fs afero.Fs) renderer {
+	return &ownersRenderer{
+		work:     work,
+		total:    ctx.total,
+		accounts: sys.ReadAccounts(fs),
+		top:      ctx.top,
+	}
+}
+
+// Worker method
+
+func (m *ownersWorker) onFile(f *scan.FileEntry) {
+	sz := m.usage.counted(f)
+
+	// Zero UID and GID would attribute such files to root
+	if !f.OwnerKnown {
+		m.unknown.Count++
+		m.unknown.Size += sz
+		return
+	}
+
+	u := m.users[f.UID]
+	u.Count++
+	u.Size += sz
+	m.users[f.UID] = u
+
+	g := m.groups[f.GID]
+	g.Count++
+	g.Size += sz
+	m.groups[f.GID] = g
+}
+
+// Renderer method
+
+func (m *ownersRenderer) print(p printer) {
+	m.printTables(p, "owners", "Owner", m.work.users, m.accounts.User)
+	m.printTables(p, "groups", "Group", m.work.groups, m.accounts.Group)
+}
+
+func (m *ownersRenderer) printTables(p printer, what string, head string, data map[uint32]countSizeAggregate, name func(uint32) string) {
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+
+	rows := make([]ownerRow, 0, len(data)+1)
+	for id, a := range data {
+		rows = append(rows, ownerRow{name: name(id), countSizeAggregate: a})
+	}
+	if m.work.unknown.Count > 0 {
+		rows = append(rows, ownerRow{name: "unknown", countSizeAggregate: m.work.unknown})
+	}
+
+	printTable := func(by string, key func(countSizeAggregate) int64) {
+		sort.Slice(rows, func(i, j int) bool {
+			ki, kj := key(rows[i].countSizeAggregate), key(rows[j].countSizeAggregate)
+			if ki == kj {
+				return rows[i].name < rows[j].name
+			}
+			return ki > kj
+		})
+
+		p.cprint("\n<gray>TOP %d %s by %s:</>\n\n", m.top, what, by)
+
+		p.print(format, head, "Count", "%", "Size", "%")
+		p.print(format, "-----", "-----", "------", "----", "------")
+
+		for i := 0; i < m.top && i < len(rows); i++ {
+			r := rows[i]
+			m.total.printCountAndSizeStatLine(p, r.Count, r.Size, r.name)
+		}
+
+		p.flush()
+	}
+
+	printTable("size", func(a countSizeAggregate) int64 { return int64(a.Size) })
+	printTable("count", func(a countSizeAggregate) int64 { return a.Count })
+}
@@ -0,0 +1,24 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+	"testing"
+)
+
+func TestOwnersWorker_OnFile(t *testing.T) {
+	w := newOwnersWorker(NewContext(10, UsageApparent))
+
+	w.onFile(&scan.FileEntry{Path: "/r/a", Size: 1, UID: 0, OwnerKnown: true})
+	w.onFile(&scan.FileEntry{Path: "/r/b", Size: 2, UID: 1000, GID: 100, OwnerKnown: true})
+	w.onFile(&scan.FileEntry{Path: "/r/c", Size: 4})
+
+	if u := w.users[0]; u.Count != 1 || u.Size != 1 {
+		t.Errorf("root %+v, want the only file of 1 byte", u)
+	}
+	if g := w.groups[100]; g.Count != 1 || g.Size != 2 {
+		t.Errorf("group 100 %+v, want the only file of 2 bytes", g)
+	}
+	if w.unknown.Count != 1 || w.unknown.Size != 4 {
+		t.Errorf("unknown %+v, want the only file of 4 bytes", w.unknown)
+	}
+}
@@ -0,0 +1,155 @@
+package module
+
+import (
+	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
+)
+
+type rootsWorker struct {
+	voidInit
+	voidFinalize
+	total *totalInfo
+	usage Usage
+
+	// roots contains scanned paths in the order they were scanned
+	roots      []string
+	aggregator map[string]countSizeAggregate
+}
+
+type rootsRenderer struct {
+	*rootsWorker
+}
+
+func newRootsWorker(ctx *Context) *rootsWorker {
+	w := rootsWorker{
+		total:      ctx.total,
+		usage:      ctx.usage,
+		roots:      make([]string, 0),
+		aggregator: make(map[string]countSizeAggregate),
+	}
+	return &w
+}
+
+func newRootsRenderer(work *rootsWorker) renderer {
+	return &rootsRenderer{work}
+}
+
+// Worker method
+
+func (m *rootsWorker) handler(evt *scan.Event) {
+	var root string
+	switch {
+	case evt.File != nil && evt.File.Archive == "":
+		root = evt.File.Root
+	case evt.Folder != nil:
+		// Paths without files are shown too
+		root = evt.Folder.Root
+	default:
+		return
+	}
+
+	a, ok := m.aggregator[root]
+	if !ok {
+		m.roots = append(m.roots, root)
+	}
+	if evt.File != nil {
+		a.Count++
+		a.Size += m.usage.counted(evt.File)
+	}
+	m.aggregator[root] = a
+}
+
+// Renderer method
+
+func (m *rootsRenderer) print(p printer) {
+	if len(m.roots) < 2 {
+		return
+	}
+
+	const format = "%v\t%v\t%v\t%v\t%v\n"
+
+	p.cprint("\n<gray>Scanned paths:</>\n\n")
+
+	p.print(format, "Path", "Files", "%", "Size", "%")
+	p.print(format, "----", "-----", "------", "----", "------")
+
+	for _, root := range m.roots {
+		a := m.aggregator[root]
+		m.total.printCountAndSizeStatLine(p, a.Count, a.Size, root)
+	}
+
+	p.flush()
+}
+
+// rootsBreakdown contains files statistic by scanned path index
+type rootsBreakdown []countSizeAggregate
+
+// add adds file to the statistic of scanned path specified by index
+func (b rootsBreakdown) add(i int, size uint64) rootsBreakdown {
+	for len(b) <= i {
+		b = append(b, countSizeAggregate{})
+	}
+	b[i].Count++
+	b[i].Size += size
+	return b
+}
+
+// root gets scanned path index. New path is added to Roots
+func (t *totalInfo) root(path string) int {
+	if t.rootIndex == nil {
+		t.rootIndex = make(map[string]int)
+	}
+	i, ok := t.rootIndex[path]
+	if !ok {
+		i = len(t.Roots)
+		t.rootIndex[path] = i
+		t.Roots = append(t.Roots, path)
+	}
+	return i
+}
+
+// multiRoot gets whether several paths were scanned so as per path columns must be shown
+func (t *totalInfo) multiRoot() bool {
+	return len(t.Roots) > 1
+}
+
+// breakdownHeads gets per scanned path columns heads. It's empty if only one path scanned
+func (t *totalInfo) breakdownHeads() []string {
+	if !t.multiRoot() {
+		return nil
+	}
+	return t.Roots
+}
+
+// breakdown gets per scanned path cells with files count and size.
+// It's empty if only one path scanned
+func (t *totalInfo) breakdown(b rootsBreakdown) []string {
+	if !t.multiRoot() {
+		return nil
+	}
+	cells := make([]string, len(t.Roots))
+	for i := range cells {
+		var a countSizeAggregate
+		if i < len(b) {
+			a = b[i]
+		}
+		cells[i] = fmt.Sprintf("%d / %s", a.Count, human(int64(a.Size)))
+	}
+	return cells
+}
+
+// rootHead gets scanned path column head. It's empty if only one path scanned
+func (t *totalInfo) rootHead() []string {
+	if !t.multiRoot() {
+		return nil
+	}
+	return []string{"Root"}
+}
+
+// rootCell gets scanned path column cell. It's empty if only one path scanned
+func (t *totalInfo) rootCell(root string) []string {
+	if !t.multiRoot() {
+		return nil
+	}
+	return []string{root}
+}
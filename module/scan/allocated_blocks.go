@@ -0,0 +1,14 @@
+//go:build !windows && !plan9 && !wasip1
+// +build !windows,!plan9,!wasip1
+
+package scan
+
+import (
+	"syscall"
+)
+
+// allocated gets the number of bytes allocated on disk for the file
+func allocated(st *syscall.Stat_t) int64 {
+	// st_blocks is always in 512 byte units regardless of file system block size
+	return int64(st.Blocks) * 512
+}
@@ -0,0 +1,13 @@
+//go:build wasip1
+// +build wasip1
+
+package scan
+
+import (
+	"syscall"
+)
+
+// allocated gets the file size since WASI doesn't provide the number of blocks allocated
+func allocated(st *syscall.Stat_t) int64 {
+	return int64(st.Size)
+}
@@ -0,0 +1,180 @@
+package scan
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+type archiveKind int
+
+const (
+	archiveNone  archiveKind = 0
+	archiveZip   archiveKind = 1
+	archiveTar   archiveKind = 2
+	archiveTarGz archiveKind = 3
+)
+
+// archiveKindOf gets archive format by file name
+func archiveKindOf(name string) archiveKind {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return archiveTar
+	}
+
+	switch filepath.Ext(lower) {
+	case ".zip", ".jar", ".war", ".ear":
+		return archiveZip
+	}
+	return archiveNone
+}
+
+// archive reads archive members as files of a folder named after the archive.
+// Member's size is its uncompressed size and allocated is its compressed size
+// (estimated proportionally for compressed tar archives)
+func (r *dirReader) archive(file string, kind archiveKind, ignored bool) ([]*filesysEntry, error) {
+	r.restrict <- struct{}{}
+	defer func() { <-r.restrict }()
+
+	f, err := r.fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer sys.Close(f)
+
+	var members []*filesysEntry
+	switch kind {
+	case archiveZip:
+		members, err = zipMembers(f)
+	case archiveTar:
+		members, err = tarMembers(f)
+	default:
+		members, err = tarGzMembers(f)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "read", Path: file, Err: err}
+	}
+
+	var result = []*filesysEntry{}
+	for _, m := range members {
+		full := filepath.Join(file, m.name)
+		if r.skip(full, false) {
+			continue
+		}
+		m.ignored = r.ignored(full, false, ignored)
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func zipMembers(f interface {
+	io.ReaderAt
+	Stat() (os.FileInfo, error)
+}) ([]*filesysEntry, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(f, fi.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*filesysEntry
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() {
+			continue
+		}
+		result = append(result, &filesysEntry{
+			name:       memberName(zf.Name),
+			size:       int64(zf.UncompressedSize64),
+			allocated:  int64(zf.CompressedSize64),
+			compressed: int64(zf.CompressedSize64),
+			mode:       zf.Mode(),
+			modTime:    zf.Modified,
+		})
+	}
+	return result, nil
+}
+
+func tarMembers(rd io.Reader) ([]*filesysEntry, error) {
+	tr := tar.NewReader(rd)
+
+	var result []*filesysEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fi := hdr.FileInfo()
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		result = append(result, &filesysEntry{
+			name:       memberName(hdr.Name),
+			size:       hdr.Size,
+			allocated:  hdr.Size,
+			compressed: hdr.Size,
+			mode:       fi.Mode(),
+			modTime:    hdr.ModTime,
+			sys:        sysInfo{uid: uint32(hdr.Uid), gid: uint32(hdr.Gid), owner: true},
+		})
+	}
+}
+
+func tarGzMembers(f interface {
+	io.Reader
+	Stat() (os.FileInfo, error)
+}) ([]*filesysEntry, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer sys.Close(gz)
+
+	result, err := tarMembers(gz)
+	if err != nil {
+		return nil, err
+	}
+
+	// The stream is compressed as a whole so share archive size
+	// between members in proportion to their sizes
+	var total int64
+	for _, m := range result {
+		total += m.size
+	}
+	if total > 0 {
+		ratio := float64(fi.Size()) / float64(total)
+		for _, m := range result {
+			m.compressed = int64(float64(m.size) * ratio)
+			m.allocated = m.compressed
+		}
+	}
+	return result, nil
+}
+
+// memberName converts archive member name into relative path
+// that can't point outside the archive
+func memberName(name string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+name), "/")
+	return filepath.FromSlash(clean)
+}
@@ -0,0 +1,37 @@
+package scan_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
+	"github.com/spf13/afero"
+	"log"
+)
+
+func ExampleScan() {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/home/a.txt", []byte("hello"), 0644)
+	_ = afero.WriteFile(fs, "/home/node_modules/b.js", []byte("skipped"), 0644)
+	_ = afero.WriteFile(fs, "/home/src/c.go", []byte("package c"), 0644)
+
+	filter, err := scan.NewGlobFilter(nil, []string{"node_modules"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var files, size int64
+	handler := func(evt *scan.Event) {
+		if evt.File != nil {
+			files++
+			size += evt.File.Size
+		}
+	}
+
+	err = scan.Scan(context.Background(), []string{"/home"}, fs, scan.Options{Filter: filter}, handler)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d files, %d bytes\n", files, size)
+	// Output: 2 files, 14 bytes
+}
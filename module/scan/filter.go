@@ -0,0 +1,171 @@
+package scan
+
+import (
+	"fmt"
+	"github.com/bmatcuk/doublestar"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Filter decides which folders and files are skipped while scanning.
+// Skipped folders are never read. Implementations must be safe for concurrent use
+type Filter interface {
+	// Skip gets whether the folder or file must be skipped.
+	// rel is the path relative to scanned path
+	Skip(rel string, isDir bool) bool
+}
+
+// rootedFilter is implemented by filters which rules depend on scanned path
+type rootedFilter interface {
+	// rooted gets the filter for scanned path specified
+	rooted(path string) Filter
+}
+
+// rootFilter gets the filter to use while scanning path specified
+func rootFilter(f Filter, path string) Filter {
+	if rf, ok := f.(rootedFilter); ok {
+		return rf.rooted(path)
+	}
+	return f
+}
+
+// globFilter implements Filter using doublestar style glob patterns
+type globFilter struct {
+	include []*glob
+	exclude []*glob
+}
+
+type glob struct {
+	pattern string
+
+	// base set if pattern has no slashes so it's matched against base name at any depth
+	base bool
+
+	// dirOnly set if pattern ends with slash so it's matched against folders only
+	dirOnly bool
+}
+
+// NewGlobFilter creates new Filter from include and exclude doublestar style glob patterns
+// (** matches any number of folders). Pattern without slashes is matched against
+// file or folder name at any depth, otherwise against the path relative to scanned path.
+// Pattern that ends with slash matches folders only. Excluded folder is never read.
+// If include patterns set only files that match any of them are reported
+// but folders are read anyway unless excluded
+func NewGlobFilter(include []string, exclude []string) (Filter, error) {
+	f := globFilter{}
+	var err error
+
+	f.include, err = newGlobs(include)
+	if err != nil {
+		return nil, err
+	}
+
+	f.exclude, err = newGlobs(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &f, nil
+}
+
+// NewFilters creates Filter that skips folders and files skipped by any of filters specified.
+// Nil filters are ignored. It returns nil if there are no filters
+func NewFilters(filters ...Filter) Filter {
+	var result multiFilter
+	for _, f := range filters {
+		if f != nil {
+			result = append(result, f)
+		}
+	}
+
+	switch len(result) {
+	case 0:
+		return nil
+	case 1:
+		return result[0]
+	default:
+		return result
+	}
+}
+
+type multiFilter []Filter
+
+func (m multiFilter) Skip(rel string, isDir bool) bool {
+	for _, f := range m {
+		if f.Skip(rel, isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiFilter) rooted(path string) Filter {
+	result := make(multiFilter, len(m))
+	for i, f := range m {
+		result[i] = rootFilter(f, path)
+	}
+	return result
+}
+
+func newGlobs(patterns []string) ([]*glob, error) {
+	var result []*glob
+	for _, p := range patterns {
+		g, err := newGlob(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, g)
+	}
+	return result, nil
+}
+
+func newGlob(p string) (*glob, error) {
+	g := glob{pattern: filepath.ToSlash(p)}
+
+	g.dirOnly = strings.HasSuffix(g.pattern, "/")
+	g.pattern = strings.TrimRight(g.pattern, "/")
+	// Leading slash anchors pattern to scanned path
+	g.base = !strings.Contains(g.pattern, "/")
+	g.pattern = strings.TrimLeft(g.pattern, "/")
+
+	if _, err := doublestar.Match(g.pattern, g.pattern); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", p, err)
+	}
+
+	return &g, nil
+}
+
+func (f *globFilter) Skip(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+
+	for _, g := range f.exclude {
+		if g.match(rel, isDir) {
+			return true
+		}
+	}
+
+	if isDir || len(f.include) == 0 {
+		return false
+	}
+
+	for _, g := range f.include {
+		if g.match(rel, isDir) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *glob) match(rel string, isDir bool) bool {
+	if g.dirOnly && !isDir {
+		return false
+	}
+
+	if g.base {
+		rel = path.Base(rel)
+	}
+
+	ok, _ := doublestar.Match(g.pattern, rel)
+	return ok
+}
@@ -0,0 +1,73 @@
+package scan
+
+import (
+	"testing"
+)
+
+func TestGlobFilter_Skip(t *testing.T) {
+	var tests = []struct {
+		name    string
+		include []string
+		exclude []string
+		rel     string
+		isDir   bool
+		skip    bool
+	}{
+		{"no patterns", nil, nil, "a/b.txt", false, false},
+		{"exclude base name at any depth", nil, []string{"*.log"}, "a/b/c.log", false, true},
+		{"exclude base name other file", nil, []string{"*.log"}, "a/b/c.txt", false, false},
+		{"exclude folder by name", nil, []string{"node_modules"}, "a/node_modules", true, true},
+		{"exclude path relative to root", nil, []string{"a/b"}, "a/b", true, true},
+		{"exclude path not matching deeper", nil, []string{"a/b"}, "x/a/b", true, false},
+		{"exclude doublestar", nil, []string{"**/tmp/**"}, "a/tmp/b/c", false, true},
+		{"exclude anchored", nil, []string{"/build"}, "build", true, true},
+		{"exclude anchored deeper", nil, []string{"/build"}, "a/build", true, false},
+		{"dir only pattern matches folder", nil, []string{"out/"}, "a/out", true, true},
+		{"dir only pattern skips file", nil, []string{"out/"}, "a/out", false, false},
+		{"include matches", []string{"*.go"}, nil, "a/b.go", false, false},
+		{"include doesn't match", []string{"*.go"}, nil, "a/b.txt", false, true},
+		{"include never skips folders", []string{"*.go"}, nil, "a", true, false},
+		{"exclude wins over include", []string{"*.go"}, []string{"*_test.go"}, "a/b_test.go", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := NewGlobFilter(test.include, test.exclude)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			skip := f.Skip(test.rel, test.isDir)
+
+			if skip != test.skip {
+				t.Errorf("Skip(%q, %v) = %v, want %v", test.rel, test.isDir, skip, test.skip)
+			}
+		})
+	}
+}
+
+func TestNewGlobFilter_InvalidPattern(t *testing.T) {
+	_, err := NewGlobFilter(nil, []string{"[a"})
+	if err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestNewFilters(t *testing.T) {
+	f1, _ := NewGlobFilter(nil, []string{"*.a"})
+	f2, _ := NewGlobFilter(nil, []string{"*.b"})
+
+	if NewFilters(nil, nil) != nil {
+		t.Error("nil filter expected if there are no filters")
+	}
+	if NewFilters(nil, f1) != f1 {
+		t.Error("the only filter expected")
+	}
+
+	f := NewFilters(f1, nil, f2)
+	for rel, skip := range map[string]bool{"x.a": true, "x.b": true, "x.c": false} {
+		if f.Skip(rel, false) != skip {
+			t.Errorf("Skip(%q) = %v, want %v", rel, !skip, skip)
+		}
+	}
+}
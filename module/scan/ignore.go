@@ -0,0 +1,175 @@
+package scan
+
+import (
+	"bufio"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/spf13/afero"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	gitignoreFile    = ".gitignore"
+	dockerignoreFile = ".dockerignore"
+	gitFolder        = ".git"
+)
+
+// ignoreFilter implements Filter that skips files and folders ignored
+// by .gitignore and .dockerignore files
+type ignoreFilter struct {
+	fs   afero.Fs
+	root string
+
+	gitignore    bool
+	dockerignore bool
+	docker       []*ignoreRule
+
+	mu sync.Mutex
+	// rules contains .gitignore rules by folder path relative to root
+	rules map[string][]*ignoreRule
+}
+
+type ignoreRule struct {
+	*glob
+
+	// negate set if rule re-includes files (starts with !)
+	negate bool
+}
+
+// NewIgnoreFilter creates Filter that skips files and folders ignored by .gitignore files
+// found in the path specified and all its subfolders (if gitignore set) and
+// by .dockerignore file in the path specified (if dockerignore set).
+// As git does it, .git folder is skipped in gitignore mode and
+// files within ignored folder cannot be re-included.
+// If several paths are scanned the rules are read from each of them
+func NewIgnoreFilter(fs afero.Fs, path string, gitignore bool, dockerignore bool) Filter {
+	f := ignoreFilter{
+		fs:           fs,
+		root:         path,
+		gitignore:    gitignore,
+		dockerignore: dockerignore,
+		rules:        make(map[string][]*ignoreRule),
+	}
+
+	if dockerignore {
+		// .dockerignore patterns are always relative to the context root
+		f.docker = f.readRules(filepath.Join(path, dockerignoreFile), func(g *glob) { g.base = false })
+	}
+
+	return &f
+}
+
+func (f *ignoreFilter) Skip(rel string, isDir bool) bool {
+	rel = filepath.ToSlash(rel)
+
+	if match(f.docker, rel, isDir) {
+		return true
+	}
+
+	if !f.gitignore {
+		return false
+	}
+
+	if isDir && path.Base(rel) == gitFolder {
+		return true
+	}
+
+	// Deeper .gitignore files take precedence
+	for dir := path.Dir(rel); ; dir = path.Dir(dir) {
+		rules := f.folderRules(dir)
+		if ignored, ok := matchLast(rules, relativeTo(dir, rel), isDir); ok {
+			return ignored
+		}
+		if dir == "." {
+			return false
+		}
+	}
+}
+
+func (f *ignoreFilter) rooted(path string) Filter {
+	if path == f.root {
+		return f
+	}
+	return NewIgnoreFilter(f.fs, path, f.gitignore, f.dockerignore)
+}
+
+// folderRules gets .gitignore rules of the folder specified relatively to root
+func (f *ignoreFilter) folderRules(dir string) []*ignoreRule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	rules, ok := f.rules[dir]
+	if !ok {
+		rules = f.readRules(filepath.Join(f.root, filepath.FromSlash(dir), gitignoreFile), nil)
+		f.rules[dir] = rules
+	}
+	return rules
+}
+
+// readRules reads ignore rules from file. Missing or unreadable file means no rules.
+// adjust is called on each rule's glob if set
+func (f *ignoreFilter) readRules(path string, adjust func(g *glob)) []*ignoreRule {
+	file, err := f.fs.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer sys.Close(file)
+
+	var rules []*ignoreRule
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+			line = line[1:]
+		}
+
+		// Skip invalid patterns like git does
+		g, err := newGlob(line)
+		if err != nil || g.pattern == "" {
+			continue
+		}
+		if adjust != nil {
+			adjust(g)
+		}
+		r.glob = g
+
+		rules = append(rules, &r)
+	}
+
+	return rules
+}
+
+// match gets whether path is ignored by rules. The last matching rule wins
+func match(rules []*ignoreRule, rel string, isDir bool) bool {
+	ignored, _ := matchLast(rules, rel, isDir)
+	return ignored
+}
+
+// matchLast gets whether path is ignored by the last matching rule.
+// It returns false as the second value if no rule matches
+func matchLast(rules []*ignoreRule, rel string, isDir bool) (bool, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].match(rel, isDir) {
+			return !rules[i].negate, true
+		}
+	}
+	return false, false
+}
+
+// relativeTo gets slash separated path rel relatively to its ancestor dir
+func relativeTo(dir string, rel string) string {
+	if dir == "." {
+		return rel
+	}
+	return strings.TrimPrefix(rel, dir+"/")
+}
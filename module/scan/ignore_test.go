@@ -0,0 +1,87 @@
+package scan
+
+import (
+	"github.com/spf13/afero"
+	"testing"
+)
+
+func newIgnoreFs() afero.Fs {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/r/.gitignore", []byte("# comment\n*.log\n!keep.log\nbuild/\n/root.txt\n"), 0644)
+	_ = afero.WriteFile(fs, "/r/sub/.gitignore", []byte("!*.log\ndeep\n"), 0644)
+	_ = afero.WriteFile(fs, "/r/.dockerignore", []byte("secret\n*.tmp\n"), 0644)
+	return fs
+}
+
+func TestIgnoreFilter_Gitignore(t *testing.T) {
+	var tests = []struct {
+		rel   string
+		isDir bool
+		skip  bool
+	}{
+		{"a.txt", false, false},
+		{"a.log", false, true},
+		{"x/y/a.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"root.txt", false, true},
+		{"x/root.txt", false, false},
+		{".git", true, true},
+		{"x/.git", true, true},
+		{"sub/a.log", false, false},
+		{"sub/deep", false, true},
+		{"deep", false, false},
+		{"secret", false, false},
+	}
+
+	f := NewIgnoreFilter(newIgnoreFs(), "/r", true, false)
+
+	for _, test := range tests {
+		t.Run(test.rel, func(t *testing.T) {
+			skip := f.Skip(test.rel, test.isDir)
+			if skip != test.skip {
+				t.Errorf("Skip(%q, %v) = %v, want %v", test.rel, test.isDir, skip, test.skip)
+			}
+		})
+	}
+}
+
+func TestIgnoreFilter_Dockerignore(t *testing.T) {
+	var tests = []struct {
+		rel  string
+		skip bool
+	}{
+		{"secret", true},
+		{"x/secret", false},
+		{"a.tmp", true},
+		{"x/a.tmp", false},
+		{"a.log", false},
+	}
+
+	f := NewIgnoreFilter(newIgnoreFs(), "/r", false, true)
+
+	for _, test := range tests {
+		t.Run(test.rel, func(t *testing.T) {
+			skip := f.Skip(test.rel, false)
+			if skip != test.skip {
+				t.Errorf("Skip(%q) = %v, want %v", test.rel, skip, test.skip)
+			}
+		})
+	}
+}
+
+func TestIgnoreFilter_Rooted(t *testing.T) {
+	fs := newIgnoreFs()
+	_ = afero.WriteFile(fs, "/other/.gitignore", []byte("*.txt\n"), 0644)
+
+	f := NewIgnoreFilter(fs, "/r", true, false)
+	other := rootFilter(f, "/other")
+
+	if !f.Skip("a.log", false) || f.Skip("a.txt", false) {
+		t.Error("/r rules expected")
+	}
+	if other.Skip("a.log", false) || !other.Skip("a.txt", false) {
+		t.Error("/other rules expected")
+	}
+}
@@ -0,0 +1,84 @@
+package scan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter paces events so as no more than rate events per second happen on average
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimiter creates new limiter. It returns nil (no limit) if rate isn't positive
+func newLimiter(rate int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Second / time.Duration(rate)}
+}
+
+// maxReserve defines the max time that one reservation of several events takes
+// so as small batches don't wait for the time reserved for the large ones
+const maxReserve = 100 * time.Millisecond
+
+// batch gets the number of events (not greater than max) to reserve at once
+func (l *limiter) batch(max int) int {
+	n := int(maxReserve / l.interval)
+	if n < 1 {
+		return 1
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// wait blocks until n events allowed to happen or ctx cancelled
+func (l *limiter) wait(ctx context.Context, n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next
+	l.next = l.next.Add(time.Duration(n) * l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// refund returns n events reserved by wait but not happened
+// so as the next ones are not delayed by them
+func (l *limiter) refund(n int) {
+	if l == nil || n <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.next = l.next.Add(-time.Duration(n) * l.interval)
+	if l.next.Before(now) {
+		l.next = now
+	}
+}
@@ -0,0 +1,114 @@
+package scan
+
+import (
+	"context"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Wait(t *testing.T) {
+	var tests = []struct {
+		name  string
+		rate  int
+		waits []int
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"no limit", 0, []int{1000, 1000}, 0, 50 * time.Millisecond},
+		{"first events are not delayed", 100, []int{5}, 0, 40 * time.Millisecond},
+		{"events are paced", 100, []int{5, 5, 5}, 100 * time.Millisecond, 300 * time.Millisecond},
+		{"single events are paced", 50, []int{1, 1, 1, 1}, 60 * time.Millisecond, 250 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newLimiter(test.rate)
+
+			start := time.Now()
+			for _, n := range test.waits {
+				l.wait(context.Background(), n)
+			}
+			elapsed := time.Since(start)
+
+			if elapsed < test.min || elapsed > test.max {
+				t.Errorf("%v elapsed, want between %v and %v", elapsed, test.min, test.max)
+			}
+		})
+	}
+}
+
+func TestLimiter_Refund(t *testing.T) {
+	l := newLimiter(100)
+
+	start := time.Now()
+	l.wait(context.Background(), 10)
+	l.refund(9)
+	l.wait(context.Background(), 1)
+	l.wait(context.Background(), 1)
+
+	// Only 2 events reserved before the last one so it waits about 20ms instead of 110ms
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Errorf("%v elapsed, refunded events must not delay the next ones", elapsed)
+	}
+}
+
+func TestLimiter_WaitCancelled(t *testing.T) {
+	l := newLimiter(1)
+	l.wait(context.Background(), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	l.wait(ctx, 1)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("%v elapsed, cancelled wait must return at once", elapsed)
+	}
+}
+
+func TestLimiter_Batch(t *testing.T) {
+	var tests = []struct {
+		rate  int
+		batch int
+	}{
+		{1, 1},
+		{10, 1},
+		{100, 10},
+		{1000, 100},
+		{100000, readdirBatch},
+	}
+
+	for _, test := range tests {
+		if b := newLimiter(test.rate).batch(readdirBatch); b != test.batch {
+			t.Errorf("batch(%d) for rate %d = %d, want %d", readdirBatch, test.rate, b, test.batch)
+		}
+	}
+}
+
+func TestDirReader_ReaddirLimited(t *testing.T) {
+	fs := newScanFs()
+	r := newDirReader(fs, Options{EntriesPerSecond: 20}, nil)
+	defer r.close()
+
+	f, err := fs.Open("/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sys.Close(f)
+
+	// The first entry reserved before reading so the second read waits for it
+	r.entries.wait(context.Background(), 1)
+
+	start := time.Now()
+	entries, err := r.readdir(context.Background(), f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("%d entries read, want 2", len(entries))
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("%v elapsed, the first batch must wait for the limiter", elapsed)
+	}
+}
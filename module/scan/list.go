@@ -0,0 +1,208 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/spf13/afero"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxListPath defines the max length of a path in files list
+const maxListPath = 64 * 1024
+
+// ScanList scans files listed in list instead of walking folders. Paths in list are separated
+// by sep (for example '\n' or 0 as find -print0 does) and can be relative to the current folder.
+// Folders and (unless following symlinks enabled) symlinks listed are skipped.
+// Each file's parent folder and all its ancestors are reported as folders.
+// name defines the list name that is used as Root of all entries and as the path of
+// list reading error. Listed paths that can't be read are reported as file read errors. MaxDepth, OneFileSystem and Archives options are not used.
+// It returns ctx error if scanning was interrupted before all events were handled
+func ScanList(ctx context.Context, name string, list io.Reader, sep byte, fs afero.Fs, opt Options, handlers ...Handler) error {
+	return scan(ctx, opt, handlers, func(counter *progressCounter, results chan<- *filesystemItem) bool {
+		return walkList(ctx, name, list, sep, fs, opt, counter, results)
+	})
+}
+
+func walkList(ctx context.Context, name string, list io.Reader, sep byte, fs afero.Fs, opt Options, counter *progressCounter, results chan<- *filesystemItem) bool {
+	reader := newDirReader(fs, opt, counter)
+	defer reader.close()
+
+	reader.setRoot("", nil)
+
+	if !send(ctx, results, &filesystemItem{dir: name, event: fsEventRoot}) {
+		return false
+	}
+
+	folders := make(map[string]*folderStat)
+	parents := listParents{reader: reader, states: make(map[string]listParent)}
+
+	sc := bufio.NewScanner(list)
+	sc.Buffer(make([]byte, 4096), maxListPath)
+	sc.Split(splitBy(sep))
+
+	for ctx.Err() == nil && sc.Scan() {
+		path := sc.Text()
+		if sep == '\n' {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path == "" {
+			continue
+		}
+		path = filepath.Clean(path)
+
+		dir := filepath.Dir(path)
+		parent := parents.state(dir)
+		if parent.skipped {
+			continue
+		}
+
+		entry, err := reader.stat(ctx, path, parent.ignored)
+		if err != nil {
+			errEvent := filesystemItem{
+				dir:   path,
+				event: fsEventFileError,
+				err:   err,
+			}
+			if !send(ctx, results, &errEvent) {
+				return false
+			}
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+
+		fileEvent := filesystemItem{
+			dir:       dir,
+			name:      entry.name,
+			event:     fsEventFile,
+			count:     1,
+			size:      entry.size,
+			allocated: entry.allocated,
+			entry:     entry,
+		}
+		if !send(ctx, results, &fileEvent) {
+			return false
+		}
+		counter.file(path, entry.size)
+
+		stat, ok := folders[dir]
+		if !ok {
+			stat = &folderStat{entries: -1}
+			folders[dir] = stat
+		}
+		stat.count++
+		if !entry.dup {
+			stat.size += entry.size
+			stat.allocated += entry.allocated
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		errEvent := filesystemItem{
+			dir:   name,
+			event: fsEventFileError,
+			err:   err,
+		}
+		send(ctx, results, &errEvent)
+	}
+
+	// Ancestors without files listed are reported too so as folders
+	// content can be rolled up into them
+	for dir := range folders {
+		for parent := filepath.Dir(dir); parent != dir; dir, parent = parent, filepath.Dir(parent) {
+			if _, ok := folders[parent]; ok {
+				break
+			}
+			folders[parent] = &folderStat{entries: -1}
+		}
+	}
+
+	for dir, stat := range folders {
+		dirEvent := filesystemItem{
+			dir:       dir,
+			event:     fsEventDir,
+			count:     stat.count,
+			size:      stat.size,
+			allocated: stat.allocated,
+			entries:   stat.entries,
+		}
+		if !send(ctx, results, &dirEvent) {
+			return false
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// listParent defines listed file's parent folder state
+type listParent struct {
+	// skipped set if the folder or any of its ancestors is skipped by filter
+	skipped bool
+
+	// ignored set if the folder or any of its ancestors is ignored
+	ignored bool
+}
+
+// listParents gets listed files parent folders state so as files are filtered
+// the same way as if their folders were walked
+type listParents struct {
+	reader *dirReader
+	states map[string]listParent
+}
+
+func (l *listParents) state(dir string) listParent {
+	if st, ok := l.states[dir]; ok {
+		return st
+	}
+
+	// The current folder and file system root are never skipped
+	st := listParent{}
+	if parent := filepath.Dir(dir); parent != dir {
+		st = l.state(parent)
+		st.skipped = st.skipped || l.reader.skip(dir, true)
+		st.ignored = l.reader.ignored(dir, true, st.ignored)
+	}
+
+	l.states[dir] = st
+	return st
+}
+
+// stat gets listed file entry. It returns nil entry if the file must be skipped.
+// ignoredDir set if the file is within ignored folder
+func (r *dirReader) stat(ctx context.Context, path string, ignoredDir bool) (*filesysEntry, error) {
+	r.entries.wait(ctx, 1)
+
+	var fi os.FileInfo
+	var err error
+	if l, ok := r.fs.(afero.Lstater); ok && r.visited == nil {
+		fi, _, err = l.LstatIfPossible(path)
+	} else {
+		fi, err = r.fs.Stat(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if fi.IsDir() || fi.Mode()&os.ModeSymlink != 0 || r.skip(path, false) {
+		return nil, nil
+	}
+
+	return r.entry(path, fi, ignoredDir), nil
+}
+
+// splitBy creates bufio.SplitFunc that splits input by separator specified
+func splitBy(sep byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
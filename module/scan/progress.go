@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProgressInterval defines how often progress reported if not set in Options
+const DefaultProgressInterval = 200 * time.Millisecond
+
+// Progress defines scanning progress info
+type Progress struct {
+	// Folders defines the number of folders read
+	Folders int64
+
+	// Files defines the number of files found
+	Files int64
+
+	// Bytes defines the size of files found
+	Bytes int64
+
+	// Queue defines the number of folders found but not read yet
+	// including ones waiting for their turn to be read
+	Queue int64
+
+	// Path defines the folder read last
+	Path string
+
+	// Done set in the last report i.e. when scanning completed or cancelled
+	Done bool
+}
+
+// ProgressHandler defines function prototype that receives scanning progress
+type ProgressHandler func(p *Progress)
+
+// progressCounter accumulates scanning progress. It's safe for concurrent use.
+// Nil counter does nothing
+type progressCounter struct {
+	// 64-bit values accessed atomically must be first to be aligned on 32-bit platforms
+	folders int64
+	files   int64
+	bytes   int64
+	queue   int64
+	path    atomic.Value
+}
+
+func newProgressCounter(h ProgressHandler) *progressCounter {
+	if h == nil {
+		return nil
+	}
+	return &progressCounter{}
+}
+
+func (c *progressCounter) folder(path string, files int64, bytes int64) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.folders, 1)
+	atomic.AddInt64(&c.files, files)
+	atomic.AddInt64(&c.bytes, bytes)
+	c.path.Store(path)
+}
+
+func (c *progressCounter) file(path string, bytes int64) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.files, 1)
+	atomic.AddInt64(&c.bytes, bytes)
+	c.path.Store(path)
+}
+
+// pending adds n folders found to read. Negative n means folders read (or skipped)
+func (c *progressCounter) pending(n int) {
+	if c == nil {
+		return
+	}
+	atomic.AddInt64(&c.queue, int64(n))
+}
+
+func (c *progressCounter) snapshot() *Progress {
+	p := Progress{
+		Folders: atomic.LoadInt64(&c.folders),
+		Files:   atomic.LoadInt64(&c.files),
+		Bytes:   atomic.LoadInt64(&c.bytes),
+		Queue:   atomic.LoadInt64(&c.queue),
+	}
+	if path, ok := c.path.Load().(string); ok {
+		p.Path = path
+	}
+	return &p
+}
+
+// report calls handler every interval until stop closed and once more after that
+func (c *progressCounter) report(h ProgressHandler, interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h(c.snapshot())
+		case <-stop:
+			p := c.snapshot()
+			p.Done = true
+			h(p)
+			return
+		}
+	}
+}
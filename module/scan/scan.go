@@ -0,0 +1,990 @@
+// Package scan is the supported API to embed dirstat's file system scanning into other programs.
+//
+// Scan walks folders breadth first reading several folders concurrently and ScanList scans
+// files listed instead. Both report each file, folder, folder reading failure and
+// skipped mount point as Event and return when scanning completes or ctx is cancelled.
+//
+// Concurrency guarantees:
+//
+//   - Handlers are called sequentially from the goroutine that called Scan or ScanList,
+//     so they need no synchronization between each other. Events must not be retained
+//     after a handler returns unless copied; entries they point to are never modified.
+//   - Handlers are called in the order they were passed for each event.
+//     Files are reported before the folder containing them is. There is no order
+//     guarantee between different folders as they are read concurrently.
+//   - Options.Progress is called from another goroutine but never concurrently.
+//     Its last call (with Progress.Done set) happens before Scan returns.
+//   - Filter implementations are called from several goroutines at once
+//     so they must be safe for concurrent use. Filters created by this package are.
+//   - Scan and ScanList can be called concurrently with different handlers.
+//     SnapshotWriter and SnapshotReader are not safe for concurrent use.
+package scan
+
+import (
+	"context"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"github.com/spf13/afero"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWorkers defines the number of folders read concurrently if not set in Options
+const DefaultWorkers = 32
+
+// readdirBatch defines the max number of entries read at once if entries reading rate limited
+const readdirBatch = 256
+
+// Options defines scanning options. Zero value scans everything without limits
+type Options struct {
+	// Workers defines the max number of folders read concurrently.
+	// DefaultWorkers used if zero
+	Workers int
+
+	// DirsPerSecond limits the number of folders read per second. Zero means no limit
+	DirsPerSecond int
+
+	// EntriesPerSecond limits the number of folder entries (files and subfolders)
+	// read per second. Zero means no limit
+	EntriesPerSecond int
+
+	// FollowSymlinks defines whether to scan symlinks targets. Each folder is read once
+	// (by the path it was reached first) so link loops are not followed. A file reached
+	// through several paths is reported by each of them but only the first one counts
+	// its bytes, others have FileEntry.Duplicate set. Links are skipped
+	// if the file system doesn't provide device and inode numbers
+	FollowSymlinks bool
+
+	// OneFileSystem defines whether to skip folders on other file systems than
+	// the scanned path is on. Each skipped folder is reported as mount point.
+	// It has no effect if the file system doesn't provide device numbers
+	OneFileSystem bool
+
+	// Filter defines folders and files to skip. Nil means no filtering
+	Filter Filter
+
+	// MaxDepth defines how deep (the scanned path has zero depth) folders are reported.
+	// Content of deeper folders is rolled up into their ancestor at MaxDepth
+	// i.e. its size and count include all its subfolders. Files are reported anyway.
+	// Zero means no limit
+	MaxDepth int
+
+	// Progress receives scanning progress every ProgressInterval and once more
+	// when scanning completes. It's never called concurrently. Nil means no reporting
+	Progress ProgressHandler
+
+	// ProgressInterval defines how often Progress is called.
+	// DefaultProgressInterval used if zero
+	ProgressInterval time.Duration
+
+	// Ignore defines folders and files to mark as ignored instead of skipping them.
+	// Files it skips and all files within folders it skips are reported
+	// with FileEntry.Ignored set. Nil means nothing is ignored
+	Ignore Filter
+
+	// Archives defines whether to scan zip (jar, war, ear), tar and tar.gz (tgz) archives
+	// content. Each archive is reported as an ordinary file followed by its members
+	// reported as files with FileEntry.Archive set and then by the archive folder
+	// i.e. folder with the archive path and FileEntry.Archive set to it containing
+	// all members. Members are not counted in any real folder.
+	// An archive that can't be read is reported as a file read error too
+	Archives bool
+}
+
+// Event defines scanning event structure that can contain file, folder,
+// read error or mount point event information. Exactly one of its fields is set
+type Event struct {
+	// File set not nil in case of file event occurred
+	File *FileEntry
+
+	// Folder set not nil in case of folder event occurred
+	Folder *FolderEntry
+
+	// Error set not nil in case of folder reading failure
+	Error *ErrorEntry
+
+	// Mount set not nil in case of folder skipped because it's on another file system
+	Mount *MountEntry
+}
+
+// FileEntry represent file description
+type FileEntry struct {
+	// File size in bytes
+	Size int64
+
+	// Allocated defines the number of bytes allocated on disk for the file.
+	// It equals to Size if the file system doesn't provide it
+	Allocated int64
+
+	// Full path
+	Path string
+
+	// Root defines the scanned path the file was found in
+	Root string
+
+	// Device and Inode identify the file on a volume. Both are zero if not available
+	Device uint64
+	Inode  uint64
+
+	// Links defines the number of hard links to the file. Zero if not available
+	Links uint64
+
+	// Duplicate set if the file is a hard link (or, if following symlinks enabled,
+	// another path) to the inode already reported by another entry
+	// so its bytes must not be counted again
+	Duplicate bool
+
+	// Ignored set if the file matches Options.Ignore
+	Ignored bool
+
+	// Mode defines file mode and permission bits including file type
+	Mode os.FileMode
+
+	// ModeKnown set if Mode is available. Zero Mode is valid
+	// (regular file without permissions) so it can't tell that
+	ModeKnown bool
+
+	// ModTime defines the last modification time
+	ModTime time.Time
+
+	// AccessTime defines the last access time.
+	// It equals to ModTime if the file system doesn't provide it
+	AccessTime time.Time
+
+	// ChangeTime defines the last metadata (inode) change time.
+	// It equals to ModTime if the file system doesn't provide it
+	ChangeTime time.Time
+
+	// UID and GID define file owner user and group. Both are zero if not available
+	UID uint32
+	GID uint32
+
+	// OwnerKnown set if UID and GID are available. Zero UID is valid (root)
+	// so it can't tell that
+	OwnerKnown bool
+
+	// Archive defines the path of the archive containing the file. Empty if the file
+	// is not an archive member. Members bytes are not on disk by themselves
+	// (the archive file is counted) so they must not be added to totals.
+	// For folders it's set to the folder path if the folder is an archive content
+	Archive string
+
+	// Compressed defines the number of bytes the archive member takes within archive.
+	// It's estimated proportionally for compressed tar archives. Allocated equals to it
+	// for archive members. Zero if the file is not an archive member
+	Compressed int64
+}
+
+// FolderEntry represent folder description. Only Size, Allocated, Path, Root, Archive and,
+// if available, Mode, ModeKnown, UID, GID and OwnerKnown of the embedded FileEntry are set.
+// ModeKnown isn't set if folder info isn't available (for example files list scanned
+// or archive folder reported)
+type FolderEntry struct {
+	FileEntry
+
+	// The number of files in a folder
+	Count int64
+
+	// Entries defines the number of entries (files, folders, symlinks and others)
+	// in the folder on disk including ones skipped by filters, symlinks not followed
+	// and, if MaxDepth set, subfolders rolled up. Negative if not known
+	// (for example files list scanned)
+	Entries int64
+}
+
+// ErrorEntry represent folder or file that could not be read
+type ErrorEntry struct {
+	// Full path of the folder or file
+	Path string
+
+	// Err contains failure cause
+	Err error
+
+	// File set if the file (listed one, archive or files list itself) could not be read
+	// instead of folder
+	File bool
+}
+
+// MountEntry represent mount point i.e. folder on another file system
+type MountEntry struct {
+	// Full path of the folder
+	Path string
+
+	// Device number of the file system mounted
+	Device uint64
+}
+
+// Handler defines function prototype that handles each file event received
+type Handler func(f *Event)
+
+type filesystemItem struct {
+	dir       string
+	name      string
+	event     fsEvent
+	count     int64
+	size      int64
+	allocated int64
+	entries   int64
+	err       error
+
+	// archive set for archive folder events
+	archive bool
+
+	// entry set for file and mount point events and, if available, for folder events
+	entry *filesysEntry
+}
+
+type filesysEntry struct {
+	isDir     bool
+	name      string
+	size      int64
+	allocated int64
+	sys       sysInfo
+	dup       bool
+	mount     bool
+	ignored   bool
+	mode      os.FileMode
+	modTime   time.Time
+
+	// archive and compressed set for archive members
+	archive    string
+	compressed int64
+}
+
+type fsEvent int
+
+const (
+	fsEventDir   fsEvent = 0
+	fsEventFile  fsEvent = 1
+	fsEventError fsEvent = 2
+	fsEventMount fsEvent = 3
+	fsEventRoot  fsEvent = 4
+
+	// fsEventFileError is the same as fsEventError but for file
+	fsEventFileError fsEvent = 5
+)
+
+// Scan do specified paths scanning one by one and executes all handlers on each event.
+// Nested or duplicate paths are scanned as many times as they are specified.
+// Scanning stops when ctx is cancelled
+// so handlers receive only events that occurred before it.
+// It returns ctx error if scanning was interrupted before all events were handled
+func Scan(ctx context.Context, paths []string, fs afero.Fs, opt Options, handlers ...Handler) error {
+	return scan(ctx, opt, handlers, func(counter *progressCounter, results chan<- *filesystemItem) bool {
+		return walkDirBreadthFirst(ctx, paths, fs, opt, counter, results)
+	})
+}
+
+// scan executes handlers on each event that walk sends. walk returns false
+// if it was interrupted. It returns ctx error if not all events were handled.
+// It returns only after walk returns so as no folders are read after that
+func scan(ctx context.Context, opt Options, handlers []Handler, walk func(counter *progressCounter, results chan<- *filesystemItem) bool) error {
+	counter := newProgressCounter(opt.Progress)
+	if counter != nil {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go counter.report(opt.Progress, opt.ProgressInterval, stop, done)
+		defer func() {
+			close(stop)
+			<-done
+		}()
+	}
+
+	filesystemCh := make(chan *filesystemItem, 1024)
+
+	// walked and converted are written before channels closed so they are read safely after that
+	var walked, converted bool
+	walkDone := make(chan struct{})
+	go func() {
+		defer close(walkDone)
+		walked = walk(counter, filesystemCh)
+		close(filesystemCh)
+	}()
+
+	scanChan := make(chan *Event, 1024)
+
+	// Reading filesystem events
+	go func() {
+		defer close(scanChan)
+		var root string
+		for item := range filesystemCh {
+			se := Event{}
+			switch item.event {
+			case fsEventRoot:
+				root = item.dir
+				continue
+			case fsEventDir:
+				fe := FileEntry{
+					Size:      item.size,
+					Allocated: item.allocated,
+					Path:      item.dir,
+					Root:      root,
+				}
+				if e := item.entry; e != nil {
+					fe.Mode = e.mode
+					fe.ModeKnown = true
+					fe.UID = e.sys.uid
+					fe.GID = e.sys.gid
+					fe.OwnerKnown = e.sys.owner
+				}
+				if item.archive {
+					fe.Archive = item.dir
+				}
+				se.Folder = &FolderEntry{
+					FileEntry: fe,
+					Count:     item.count,
+					Entries:   item.entries,
+				}
+			case fsEventError, fsEventFileError:
+				se.Error = &ErrorEntry{
+					Path: item.dir,
+					Err:  item.err,
+					File: item.event == fsEventFileError,
+				}
+			case fsEventMount:
+				se.Mount = &MountEntry{
+					Path:   filepath.Join(item.dir, item.name),
+					Device: item.entry.sys.id.dev,
+				}
+			default:
+				se.File = newFileEntry(filepath.Join(item.dir, item.name), item.entry)
+				se.File.Root = root
+			}
+			select {
+			case scanChan <- &se:
+			case <-ctx.Done():
+				return
+			}
+		}
+		converted = walked
+	}()
+
+	// Read all files from channel
+	for {
+		select {
+		case file, ok := <-scanChan:
+			if !ok {
+				if converted {
+					return nil
+				}
+				return interrupted(ctx)
+			}
+			for _, h := range handlers {
+				h(file)
+			}
+		case <-ctx.Done():
+			// Walking stops soon since sending and reading pending folders respect ctx
+			<-walkDone
+			return interrupted(ctx)
+		}
+	}
+}
+
+// interrupted gets ctx error or context.Canceled if there is no error yet
+func interrupted(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return context.Canceled
+}
+
+func newFileEntry(path string, e *filesysEntry) *FileEntry {
+	f := FileEntry{
+		Size:       e.size,
+		Allocated:  e.allocated,
+		Path:       path,
+		Device:     e.sys.id.dev,
+		Inode:      e.sys.id.ino,
+		Links:      e.sys.nlink,
+		Duplicate:  e.dup,
+		Ignored:    e.ignored,
+		Mode:       e.mode,
+		ModeKnown:  true,
+		ModTime:    e.modTime,
+		AccessTime: e.sys.atime,
+		ChangeTime: e.sys.ctime,
+		UID:        e.sys.uid,
+		GID:        e.sys.gid,
+		OwnerKnown: e.sys.owner,
+		Archive:    e.archive,
+		Compressed: e.compressed,
+	}
+
+	if f.AccessTime.IsZero() {
+		f.AccessTime = f.ModTime
+	}
+	if f.ChangeTime.IsZero() {
+		f.ChangeTime = f.ModTime
+	}
+
+	return &f
+}
+
+// send sends item into results channel unless ctx is cancelled.
+// It returns false if item wasn't sent
+func send(ctx context.Context, results chan<- *filesystemItem, item *filesystemItem) bool {
+	select {
+	case results <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// walkDirBreadthFirst walks paths one by one. Each path walking starts from root event.
+// Hard links and (if following symlinks enabled) files reached are tracked across paths
+// so they are counted once. It returns false if walking was interrupted
+func walkDirBreadthFirst(ctx context.Context, paths []string, fs afero.Fs, opt Options, counter *progressCounter, results chan<- *filesystemItem) bool {
+	reader := newDirReader(fs, opt, counter)
+	defer reader.close()
+
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return false
+		}
+		if !send(ctx, results, &filesystemItem{dir: path, event: fsEventRoot}) {
+			return false
+		}
+		walkRoot(ctx, path, reader, opt, results)
+	}
+	return ctx.Err() == nil
+}
+
+// walkRoot walks path until all its folders are read
+func walkRoot(ctx context.Context, path string, reader *dirReader, opt Options, results chan<- *filesystemItem) {
+	counter := reader.counter
+	fi, err := reader.fs.Stat(path)
+	if err == nil {
+		reader.visit(fi)
+	}
+	reader.setRoot(path, fi)
+
+	var wg sync.WaitGroup
+	var mu sync.RWMutex
+	queue := make([]string, 0)
+
+	queue = append(queue, path)
+	counter.pending(1)
+
+	ql := len(queue)
+
+	for ql > 0 {
+		// Peek
+		mu.RLock()
+		currentDir := queue[0]
+		mu.RUnlock()
+
+		wg.Add(1)
+		go func(d string) {
+			defer wg.Done()
+			// The folder is pending until it's read. Its subfolders are counted before that
+			defer counter.pending(-1)
+
+			// Skip all pending folders if cancelled
+			if ctx.Err() != nil {
+				return
+			}
+
+			rollup := opt.MaxDepth > 0 && reader.depth(d) >= opt.MaxDepth
+			stat, subdirs, ok := readDir(ctx, reader, d, results)
+			if !ok {
+				return
+			}
+			counter.folder(d, stat.count, stat.size)
+
+			if rollup {
+				// Read all subfolders here to roll their content up into the folder
+				counter.pending(len(subdirs))
+				for len(subdirs) > 0 && ctx.Err() == nil {
+					last := len(subdirs) - 1
+					sd := subdirs[last]
+					subdirs = subdirs[:last]
+
+					if s, more, ok := readDir(ctx, reader, sd, results); ok {
+						counter.folder(sd, s.count, s.size)
+						stat.add(s)
+						subdirs = append(subdirs, more...)
+						counter.pending(len(more))
+					}
+					counter.pending(-1)
+				}
+				// Subfolders left if cancelled
+				counter.pending(-len(subdirs))
+			} else {
+				// Push
+				mu.Lock()
+				queue = append(queue, subdirs...)
+				counter.pending(len(subdirs))
+				mu.Unlock()
+			}
+
+			dirEvent := filesystemItem{
+				dir:       d,
+				event:     fsEventDir,
+				count:     stat.count,
+				size:      stat.size,
+				allocated: stat.allocated,
+				entries:   stat.entries,
+				entry:     stat.entry,
+			}
+			send(ctx, results, &dirEvent)
+		}(currentDir)
+
+		// Pop
+		mu.Lock()
+		queue = queue[1:]
+		ql = len(queue)
+		mu.Unlock()
+
+		if ql == 0 {
+			// Waiting pending goroutines
+			wg.Wait()
+
+			mu.RLock()
+			ql = len(queue)
+			mu.RUnlock()
+		}
+	}
+}
+
+// folderStat contains statistic of files within folder
+type folderStat struct {
+	count     int64
+	size      int64
+	allocated int64
+
+	// entries defines the number of the folder own entries on disk. Negative if not known
+	entries int64
+
+	// entry defines the folder itself. Nil if its info is not available
+	entry *filesysEntry
+}
+
+// add adds other folder files statistic. Entries are not added since
+// they are the folder own entries on disk
+func (f *folderStat) add(other *folderStat) {
+	f.count += other.count
+	f.size += other.size
+	f.allocated += other.allocated
+}
+
+// readDir reads folder sending its files, errors and mount points events.
+// It returns the folder files statistic and subfolders to read.
+// Archives members are reported after the archive file but not added to the statistic.
+// It returns false if the folder wasn't read or scanning was cancelled
+func readDir(ctx context.Context, reader *dirReader, d string, results chan<- *filesystemItem) (*folderStat, []string, bool) {
+	self, entries, raw, err := reader.dirents(ctx, d)
+
+	if ctx.Err() != nil {
+		return nil, nil, false
+	}
+	if err != nil {
+		errEvent := filesystemItem{
+			dir:   d,
+			event: fsEventError,
+			err:   err,
+		}
+		send(ctx, results, &errEvent)
+		return nil, nil, false
+	}
+
+	stat := folderStat{entry: self, entries: raw}
+	var subdirs []string
+
+	for _, entry := range entries {
+		if entry.mount {
+			mountEvent := filesystemItem{
+				dir:   d,
+				name:  entry.name,
+				event: fsEventMount,
+				entry: entry,
+			}
+			if !send(ctx, results, &mountEvent) {
+				return nil, nil, false
+			}
+			continue
+		}
+
+		if entry.isDir {
+			subdirs = append(subdirs, filepath.Join(d, entry.name))
+			continue
+		}
+
+		// Send to channel
+		fileEvent := filesystemItem{
+			dir:       d,
+			name:      entry.name,
+			event:     fsEventFile,
+			count:     1,
+			size:      entry.size,
+			allocated: entry.allocated,
+			entry:     entry,
+		}
+		if !send(ctx, results, &fileEvent) {
+			return nil, nil, false
+		}
+
+		// update folder stat
+		stat.count++
+		if !entry.dup {
+			stat.size += entry.size
+			stat.allocated += entry.allocated
+		}
+
+		if kind := reader.archiveKind(entry); kind != archiveNone {
+			if !readArchive(ctx, reader, filepath.Join(d, entry.name), kind, entry.ignored, results) {
+				return nil, nil, false
+			}
+		}
+	}
+
+	return &stat, subdirs, true
+}
+
+// readArchive sends archive members events followed by the archive folder event.
+// Members are not counted in any real folder as the archive file itself is.
+// Archive reading failure is sent as file read error.
+// It returns false if scanning was cancelled
+func readArchive(ctx context.Context, reader *dirReader, file string, kind archiveKind, ignored bool, results chan<- *filesystemItem) bool {
+	members, err := reader.archive(file, kind, ignored)
+	if err != nil {
+		errEvent := filesystemItem{
+			dir:   file,
+			event: fsEventFileError,
+			err:   err,
+		}
+		return send(ctx, results, &errEvent)
+	}
+
+	folderEvent := filesystemItem{
+		dir:     file,
+		event:   fsEventDir,
+		archive: true,
+	}
+	for _, m := range members {
+		m.archive = file
+		fileEvent := filesystemItem{
+			dir:       file,
+			name:      m.name,
+			event:     fsEventFile,
+			count:     1,
+			size:      m.size,
+			allocated: m.allocated,
+			entry:     m,
+		}
+		if !send(ctx, results, &fileEvent) {
+			return false
+		}
+		folderEvent.count++
+		folderEvent.size += m.size
+		folderEvent.allocated += m.allocated
+	}
+	folderEvent.entries = folderEvent.count
+
+	return send(ctx, results, &folderEvent)
+}
+
+// dirReader reads folders content restricting concurrency and reading rate
+type dirReader struct {
+	fs       afero.Fs
+	restrict chan struct{}
+	dirs     *limiter
+	entries  *limiter
+
+	// visited is set only if following symlinks enabled.
+	// It contains all folders reached
+	visited *idSet
+
+	// links contains files that have several hard links or, if following
+	// symlinks enabled, all files reached
+	links *idSet
+
+	// oneFileSystem set if folders on other devices than rootDev must be skipped
+	oneFileSystem bool
+	rootDev       uint64
+
+	// root, filter and ignore are set for the path being scanned
+	root   string
+	filter Filter
+	ignore Filter
+	opt    Options
+
+	// ignoredDirs contains folders that Options.Ignore skips
+	ignoredMu   sync.RWMutex
+	ignoredDirs map[string]struct{}
+
+	// archives set if archives content must be read
+	archives bool
+	counter  *progressCounter
+}
+
+func newDirReader(fs afero.Fs, opt Options, counter *progressCounter) *dirReader {
+	workers := opt.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	r := dirReader{
+		fs:       fs,
+		restrict: make(chan struct{}, workers),
+		dirs:     newLimiter(opt.DirsPerSecond),
+		entries:  newLimiter(opt.EntriesPerSecond),
+		links:    newIDSet(),
+
+		opt:         opt,
+		ignoredDirs: make(map[string]struct{}),
+		archives:    opt.Archives,
+		counter:     counter,
+	}
+
+	if opt.FollowSymlinks {
+		r.visited = newIDSet()
+	}
+
+	return &r
+}
+
+func (r *dirReader) close() {
+	close(r.restrict)
+}
+
+// dirents reads folder entries. It also returns the folder itself entry
+// or nil if its info can't be read and the number of entries on disk
+// including ones skipped
+func (r *dirReader) dirents(ctx context.Context, path string) (*filesysEntry, []*filesysEntry, int64, error) {
+	r.restrict <- struct{}{}
+	defer func() { <-r.restrict }()
+
+	r.dirs.wait(ctx, 1)
+
+	// Folders waiting for their turn are not read after scanning cancelled
+	if err := ctx.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	f, err := r.fs.Open(path)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer sys.Close(f)
+
+	entries, err := r.readdir(ctx, f)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	// Partially read folder is not reported
+	if err := ctx.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	var self *filesysEntry
+	if fi, err := f.Stat(); err == nil {
+		self = &filesysEntry{name: fi.Name(), isDir: true, mode: fi.Mode()}
+		self.sys, _ = getSysInfo(fi)
+	}
+
+	ignoredDir := r.ignoredDir(path)
+
+	var result = []*filesysEntry{}
+	for _, e := range entries {
+		name := e.Name()
+		full := filepath.Join(path, name)
+		if e.Mode()&os.ModeSymlink != 0 {
+			target, ok := r.resolve(full)
+			if !ok || r.skip(full, target.IsDir()) {
+				continue
+			}
+			e = target
+		} else if r.skip(full, e.IsDir()) || (e.IsDir() && !r.visit(e)) {
+			continue
+		}
+
+		fi := r.entry(full, e, ignoredDir)
+		result = append(result, fi)
+	}
+
+	return self, result, int64(len(entries)), nil
+}
+
+// archiveKind gets archive format of the file if its content must be read.
+// A hard link to already reported archive is not read again
+func (r *dirReader) archiveKind(e *filesysEntry) archiveKind {
+	if !r.archives || e.dup {
+		return archiveNone
+	}
+	return archiveKindOf(e.name)
+}
+
+// entry creates folder or file entry from its info.
+// inherited set if it's within ignored folder
+func (r *dirReader) entry(path string, e os.FileInfo, inherited bool) *filesysEntry {
+	fi := filesysEntry{name: e.Name(), size: e.Size(), allocated: e.Size(), isDir: e.IsDir()}
+	fi.ignored = r.ignored(path, fi.isDir, inherited)
+	if fi.isDir && r.oneFileSystem {
+		var ok bool
+		fi.sys, ok = getSysInfo(e)
+		fi.mount = ok && fi.sys.id.dev != r.rootDev
+	}
+	if !fi.isDir {
+		fi.mode = e.Mode()
+		fi.modTime = e.ModTime()
+
+		var ok bool
+		fi.sys, ok = getSysInfo(e)
+		if ok {
+			fi.allocated = fi.sys.allocated
+		}
+		// Only the first path to an inode counts its bytes. If following symlinks enabled
+		// any file can be reached through several paths, otherwise only hard links
+		fi.dup = ok && (fi.sys.nlink > 1 || r.visited != nil) && !r.links.add(fi.sys.id)
+	}
+	return &fi
+}
+
+// resolve gets symlink target info. It returns false if the link must be skipped i.e.
+// following symlinks disabled, target is missing or has no identity,
+// or target folder was already reached (links loop or several paths to the same folder).
+// File targets are never skipped here. They are reported as duplicates if already reached
+func (r *dirReader) resolve(path string) (os.FileInfo, bool) {
+	if r.visited == nil {
+		return nil, false
+	}
+
+	target, err := r.fs.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+
+	id, ok := getFileID(target)
+	if !ok {
+		return nil, false
+	}
+
+	if !target.IsDir() {
+		return target, true
+	}
+	return target, r.visited.add(id)
+}
+
+// depth gets folder depth relatively to scanned path
+func (r *dirReader) depth(path string) int {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// rel gets path relative to scanned path that filters match.
+// If there is no scanned path (files list scanned) it's the path itself
+func (r *dirReader) rel(path string) (string, error) {
+	if r.root == "" {
+		return path, nil
+	}
+	return filepath.Rel(r.root, path)
+}
+
+// skip gets whether the folder or file must be skipped by filter
+func (r *dirReader) skip(path string, isDir bool) bool {
+	if r.filter == nil {
+		return false
+	}
+
+	rel, err := r.rel(path)
+	if err != nil {
+		return false
+	}
+
+	return r.filter.Skip(rel, isDir)
+}
+
+// ignored gets whether the folder or file must be marked as ignored.
+// inherited set if it's within ignored folder. Ignored folder is remembered
+// so as all its content is ignored too
+func (r *dirReader) ignored(path string, isDir bool, inherited bool) bool {
+	if r.ignore == nil {
+		return false
+	}
+
+	if !inherited {
+		rel, err := r.rel(path)
+		if err != nil || !r.ignore.Skip(rel, isDir) {
+			return false
+		}
+	}
+
+	if isDir {
+		r.ignoredMu.Lock()
+		r.ignoredDirs[path] = struct{}{}
+		r.ignoredMu.Unlock()
+	}
+	return true
+}
+
+// ignoredDir gets whether the folder was marked as ignored
+func (r *dirReader) ignoredDir(path string) bool {
+	r.ignoredMu.RLock()
+	defer r.ignoredMu.RUnlock()
+	_, ok := r.ignoredDirs[path]
+	return ok
+}
+
+// setRoot remembers scanned path and the device it's on and sets filters up for it.
+// fi is nil if the path can't be read
+func (r *dirReader) setRoot(path string, fi os.FileInfo) {
+	r.root = path
+	r.filter = rootFilter(r.opt.Filter, path)
+	r.ignore = rootFilter(r.opt.Ignore, path)
+	r.oneFileSystem = false
+
+	if fi == nil {
+		return
+	}
+	if id, ok := getFileID(fi); ok {
+		r.rootDev = id.dev
+		r.oneFileSystem = r.opt.OneFileSystem
+	}
+}
+
+// visit marks folder as reached if following symlinks enabled.
+// It returns false if it was already reached through another path
+func (r *dirReader) visit(fi os.FileInfo) bool {
+	if r.visited == nil {
+		return true
+	}
+
+	id, ok := getFileID(fi)
+	if !ok {
+		return true
+	}
+	return r.visited.add(id)
+}
+
+// readdir reads all folder entries. If entries reading rate limited
+// it reads them by batches waiting for the limiter before each one.
+// Entries reserved but not read (the last batch is usually smaller) are refunded
+func (r *dirReader) readdir(ctx context.Context, f afero.File) ([]os.FileInfo, error) {
+	if r.entries == nil {
+		return f.Readdir(-1)
+	}
+
+	n := r.entries.batch(readdirBatch)
+
+	var result []os.FileInfo
+	for ctx.Err() == nil {
+		r.entries.wait(ctx, n)
+		batch, err := f.Readdir(n)
+		r.entries.refund(n - len(batch))
+		result = append(result, batch...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,157 @@
+package scan
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/afero"
+	"testing"
+	"time"
+)
+
+func newScanFs() afero.Fs {
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/r/a/f1", []byte("1"), 0644)
+	_ = afero.WriteFile(fs, "/r/a/b/f2", []byte("22"), 0644)
+	_ = afero.WriteFile(fs, "/r/c/f3", []byte("333"), 0644)
+	return fs
+}
+
+func TestScan(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var tests = []struct {
+		name    string
+		ctx     context.Context
+		err     error
+		files   int
+		folders int
+	}{
+		{"completed", context.Background(), nil, 3, 4},
+		{"cancelled before start", cancelled, context.Canceled, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var files, folders int
+			err := Scan(test.ctx, []string{"/r"}, newScanFs(), Options{}, func(evt *Event) {
+				switch {
+				case evt.File != nil:
+					files++
+				case evt.Folder != nil:
+					folders++
+				}
+			})
+
+			if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+			if files != test.files || folders != test.folders {
+				t.Errorf("%d files and %d folders, want %d and %d", files, folders, test.files, test.folders)
+			}
+		})
+	}
+}
+
+func TestScan_ProgressQueue(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for i := 0; i < 20; i++ {
+		_ = fs.MkdirAll(fmt.Sprintf("/r/d%02d", i), 0755)
+	}
+
+	var max, last int64
+	opt := Options{
+		Workers:          1,
+		DirsPerSecond:    200,
+		ProgressInterval: 10 * time.Millisecond,
+		Progress: func(p *Progress) {
+			if p.Queue > max {
+				max = p.Queue
+			}
+			last = p.Queue
+		},
+	}
+
+	if err := Scan(context.Background(), []string{"/r"}, fs, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	// Folders waiting for their turn are in the queue too
+	if max < 10 {
+		t.Errorf("max queue %d, want at least 10", max)
+	}
+	if last != 0 {
+		t.Errorf("queue %d after scanning, want 0", last)
+	}
+}
+
+func TestScan_Archive(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, m := range []string{"x", "d/y"} {
+		_ = tw.WriteHeader(&tar.Header{Name: m, Size: 4, Mode: 0644, Typeflag: tar.TypeReg})
+		_, _ = tw.Write([]byte("data"))
+	}
+	_ = tw.Close()
+
+	fs := afero.NewMemMapFs()
+	_ = afero.WriteFile(fs, "/r/a.tar", buf.Bytes(), 0644)
+
+	var members int
+	var folders []*FolderEntry
+	err := Scan(context.Background(), []string{"/r"}, fs, Options{Archives: true}, func(evt *Event) {
+		switch {
+		case evt.File != nil && evt.File.Archive != "":
+			members++
+		case evt.Folder != nil:
+			folders = append(folders, evt.Folder)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if members != 2 {
+		t.Errorf("%d members, want 2", members)
+	}
+	var archive *FolderEntry
+	for _, f := range folders {
+		if f.Path == "/r" && (f.Count != 1 || f.Size != int64(buf.Len())) {
+			t.Errorf("archive members counted in real folder %+v", f)
+		}
+		if f.Archive != "" {
+			archive = f
+		}
+	}
+	if archive == nil || archive.Path != "/r/a.tar" || archive.Archive != archive.Path || archive.Count != 2 || archive.Size != 8 {
+		t.Errorf("archive folder %+v, want /r/a.tar with 2 files of 8 bytes", archive)
+	}
+}
+
+func TestScanList_MissingFile(t *testing.T) {
+	list := bytes.NewBufferString("/r/a/f1\n/r/missing\n")
+
+	var files int
+	var errs []*ErrorEntry
+	err := ScanList(context.Background(), "list", list, '\n', newScanFs(), Options{}, func(evt *Event) {
+		switch {
+		case evt.File != nil:
+			files++
+		case evt.Error != nil:
+			errs = append(errs, evt.Error)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if files != 1 {
+		t.Errorf("%d files, want 1", files)
+	}
+	if len(errs) != 1 || errs[0].Path != "/r/missing" || !errs[0].File {
+		t.Errorf("file error for /r/missing expected but got %+v", errs)
+	}
+}
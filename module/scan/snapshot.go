@@ -0,0 +1,344 @@
+package scan
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/aegoroff/dirstat/module/internal/sys"
+	"io"
+	"os"
+	"time"
+)
+
+// snapshotMagic starts each snapshot file
+const snapshotMagic = "dirstat snapshot"
+
+// SnapshotVersion defines the version of snapshot format written.
+// Snapshots of other versions can't be read
+const SnapshotVersion = 2
+
+// ErrNotSnapshot returned when reading something that isn't snapshot
+var ErrNotSnapshot = errors.New("not a dirstat snapshot")
+
+// ErrIncompleteSnapshot returned after reading snapshot taken by interrupted scanning
+// or snapshot which writing didn't complete
+var ErrIncompleteSnapshot = errors.New("snapshot was taken by interrupted scanning")
+
+type recordKind byte
+
+const (
+	recordRoot   recordKind = 0
+	recordFile   recordKind = 1
+	recordFolder recordKind = 2
+	recordError  recordKind = 3
+	recordMount  recordKind = 4
+	recordEnd    recordKind = 5
+)
+
+// snapshotHeader is written once after magic and version.
+// Whether scanning completed isn't known at this point so it's written
+// by the end record that closes snapshot
+type snapshotHeader struct {
+	Created time.Time
+}
+
+// snapshotRecord defines one scanning event. Zero fields take no space
+// so only fields that event has are written. Times are unix nanoseconds
+type snapshotRecord struct {
+	Kind       recordKind
+	Path       string
+	Size       int64
+	Allocated  int64
+	Count      int64
+	Entries    int64
+	Device     uint64
+	Inode      uint64
+	Links      uint64
+	Duplicate  bool
+	Ignored    bool
+	Mode       uint32
+	ModeKnown  bool
+	ModTime    int64
+	AccessTime int64
+	ChangeTime int64
+	UID        uint32
+	GID        uint32
+	Archive    string
+	Compressed int64
+	Err        string
+	FileError  bool
+	Incomplete bool
+
+	// OwnerUnknown is stored inverted so as owners of entries written
+	// before it was added are known as they were
+	OwnerUnknown bool
+}
+
+// SnapshotWriter writes scanning events into snapshot i.e. gzip compressed
+// stream of records prefixed by magic and format version. Its Write method
+// can be used as Handler. It's not safe for concurrent use
+type SnapshotWriter struct {
+	bw         *bufio.Writer
+	gz         *gzip.Writer
+	enc        *gob.Encoder
+	root       string
+	count      int64
+	incomplete bool
+	err        error
+}
+
+// NewSnapshotWriter creates new SnapshotWriter that writes into w.
+// Close must be called when writing completes
+func NewSnapshotWriter(w io.Writer) (*SnapshotWriter, error) {
+	bw := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "%s %d\n", snapshotMagic, SnapshotVersion); err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(bw)
+	s := SnapshotWriter{bw: bw, gz: gz, enc: gob.NewEncoder(gz)}
+
+	if err := s.enc.Encode(&snapshotHeader{Created: time.Now()}); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Write writes event into snapshot. It can be used as Handler.
+// After the first failure nothing is written and Close returns the failure
+func (s *SnapshotWriter) Write(evt *Event) {
+	if s.err != nil {
+		return
+	}
+
+	var r snapshotRecord
+	var root string
+	switch {
+	case evt.File != nil:
+		r = newFileRecord(recordFile, evt.File)
+		root = evt.File.Root
+	case evt.Folder != nil:
+		r = newFileRecord(recordFolder, &evt.Folder.FileEntry)
+		r.Count = evt.Folder.Count
+		r.Entries = evt.Folder.Entries
+		root = evt.Folder.Root
+	case evt.Error != nil:
+		r = snapshotRecord{Kind: recordError, Path: evt.Error.Path, Err: evt.Error.Err.Error(), FileError: evt.Error.File}
+		root = s.root
+	case evt.Mount != nil:
+		r = snapshotRecord{Kind: recordMount, Path: evt.Mount.Path, Device: evt.Mount.Device}
+		root = s.root
+	default:
+		return
+	}
+
+	// Root is written only when it changes
+	if root != s.root || s.count == 0 {
+		s.root = root
+		s.err = s.enc.Encode(&snapshotRecord{Kind: recordRoot, Path: root})
+		if s.err != nil {
+			return
+		}
+	}
+
+	s.err = s.enc.Encode(&r)
+	if s.err == nil {
+		s.count++
+	}
+}
+
+// Count gets the number of events written
+func (s *SnapshotWriter) Count() int64 {
+	return s.count
+}
+
+// SetIncomplete marks snapshot as taken by interrupted scanning
+// so as its reader reports results as incomplete
+func (s *SnapshotWriter) SetIncomplete() {
+	s.incomplete = true
+}
+
+// Close writes the end record and flushes all written into the underlying writer.
+// It returns the first failure occurred while writing
+func (s *SnapshotWriter) Close() error {
+	if s.err == nil {
+		s.err = s.enc.Encode(&snapshotRecord{Kind: recordEnd, Incomplete: s.incomplete})
+	}
+	if err := s.gz.Close(); err != nil && s.err == nil {
+		s.err = err
+	}
+	if err := s.bw.Flush(); err != nil && s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
+
+func newFileRecord(kind recordKind, f *FileEntry) snapshotRecord {
+	r := snapshotRecord{
+		Kind:       kind,
+		Path:       f.Path,
+		Size:       f.Size,
+		Allocated:  f.Allocated,
+		Device:     f.Device,
+		Inode:      f.Inode,
+		Links:      f.Links,
+		Duplicate:  f.Duplicate,
+		Ignored:    f.Ignored,
+		Mode:       uint32(f.Mode),
+		ModeKnown:  f.ModeKnown,
+		UID:        f.UID,
+		GID:        f.GID,
+		Archive:    f.Archive,
+		Compressed: f.Compressed,
+
+		OwnerUnknown: !f.OwnerKnown,
+	}
+
+	// Times are only set for files. AccessTime and ChangeTime fall back to ModTime
+	// so they are written only if they differ
+	if !f.ModTime.IsZero() {
+		r.ModTime = f.ModTime.UnixNano()
+		if !f.AccessTime.Equal(f.ModTime) {
+			r.AccessTime = f.AccessTime.UnixNano()
+		}
+		if !f.ChangeTime.Equal(f.ModTime) {
+			r.ChangeTime = f.ChangeTime.UnixNano()
+		}
+	}
+	return r
+}
+
+// SnapshotReader reads scanning events from snapshot written by SnapshotWriter
+type SnapshotReader struct {
+	// Created defines when the snapshot was taken
+	Created time.Time
+
+	// Incomplete set after reading if the snapshot was taken by interrupted scanning
+	// or its writing didn't complete
+	Incomplete bool
+
+	gz  *gzip.Reader
+	dec *gob.Decoder
+}
+
+// NewSnapshotReader creates new SnapshotReader that reads from r.
+// It fails if r isn't snapshot or snapshot format version isn't supported
+func NewSnapshotReader(r io.Reader) (*SnapshotReader, error) {
+	br := bufio.NewReader(r)
+
+	prefix := snapshotMagic + " "
+	if p, err := br.Peek(len(prefix)); err != nil || string(p) != prefix {
+		return nil, ErrNotSnapshot
+	}
+	_, _ = br.Discard(len(prefix))
+
+	var version int
+	if _, err := fmt.Fscanf(br, "%d\n", &version); err != nil {
+		return nil, ErrNotSnapshot
+	}
+	if version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d. Only version %d supported", version, SnapshotVersion)
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	s := SnapshotReader{gz: gz, dec: gob.NewDecoder(gz)}
+
+	var h snapshotHeader
+	if err := s.dec.Decode(&h); err != nil {
+		return nil, err
+	}
+	s.Created = h.Created
+
+	return &s, nil
+}
+
+// Scan executes handlers on each event in snapshot in the order they were written.
+// Reading stops when ctx is cancelled. It returns reading failure if any,
+// ctx error if reading was interrupted or ErrIncompleteSnapshot if all events were read
+// but the snapshot itself is incomplete
+func (s *SnapshotReader) Scan(ctx context.Context, handlers ...Handler) error {
+	defer sys.Close(s.gz)
+
+	var root string
+	for ctx.Err() == nil {
+		var r snapshotRecord
+		err := s.dec.Decode(&r)
+		if err == io.EOF {
+			// Snapshot writing was interrupted before the end record written
+			s.Incomplete = true
+			return ErrIncompleteSnapshot
+		}
+		if err != nil {
+			return err
+		}
+
+		evt := Event{}
+		switch r.Kind {
+		case recordRoot:
+			root = r.Path
+			continue
+		case recordFile:
+			evt.File = r.fileEntry(root)
+		case recordFolder:
+			evt.Folder = &FolderEntry{FileEntry: *r.fileEntry(root), Count: r.Count, Entries: r.Entries}
+		case recordError:
+			evt.Error = &ErrorEntry{Path: r.Path, Err: errors.New(r.Err), File: r.FileError}
+		case recordMount:
+			evt.Mount = &MountEntry{Path: r.Path, Device: r.Device}
+		case recordEnd:
+			if r.Incomplete {
+				s.Incomplete = true
+				return ErrIncompleteSnapshot
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown snapshot record %d", r.Kind)
+		}
+
+		for _, h := range handlers {
+			h(&evt)
+		}
+	}
+	return ctx.Err()
+}
+
+func (r *snapshotRecord) fileEntry(root string) *FileEntry {
+	f := FileEntry{
+		Size:       r.Size,
+		Allocated:  r.Allocated,
+		Path:       r.Path,
+		Root:       root,
+		Device:     r.Device,
+		Inode:      r.Inode,
+		Links:      r.Links,
+		Duplicate:  r.Duplicate,
+		Ignored:    r.Ignored,
+		Mode:       os.FileMode(r.Mode),
+		ModeKnown:  r.ModeKnown,
+		UID:        r.UID,
+		GID:        r.GID,
+		OwnerKnown: !r.OwnerUnknown,
+		Archive:    r.Archive,
+		Compressed: r.Compressed,
+	}
+
+	if r.ModTime != 0 {
+		f.ModTime = time.Unix(0, r.ModTime)
+		f.AccessTime = f.ModTime
+		f.ChangeTime = f.ModTime
+		if r.AccessTime != 0 {
+			f.AccessTime = time.Unix(0, r.AccessTime)
+		}
+		if r.ChangeTime != 0 {
+			f.ChangeTime = time.Unix(0, r.ChangeTime)
+		}
+	}
+	return &f
+}
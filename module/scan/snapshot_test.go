@@ -0,0 +1,163 @@
+package scan
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	mod := time.Unix(1600000000, 0)
+	access := time.Unix(1600000100, 0)
+
+	file := &FileEntry{
+		Size:       100,
+		Allocated:  4096,
+		Path:       "/r/a/f.txt",
+		Root:       "/r",
+		Device:     1,
+		Inode:      2,
+		Links:      2,
+		Duplicate:  true,
+		Mode:       0644,
+		ModeKnown:  true,
+		ModTime:    mod,
+		AccessTime: access,
+		ChangeTime: mod,
+		UID:        1000,
+		GID:        100,
+		OwnerKnown: true,
+	}
+	member := &FileEntry{Size: 10, Path: "/r/a.zip/x", Root: "/r", Archive: "/r/a.zip", Compressed: 5}
+	folder := &FolderEntry{FileEntry: FileEntry{Size: 100, Path: "/r/a", Root: "/r", Mode: os.ModeDir | 0755, ModeKnown: true}, Count: 1, Entries: 2}
+	other := &FolderEntry{FileEntry: FileEntry{Path: "/o", Root: "/o"}, Entries: -1}
+
+	var tests = []struct {
+		name       string
+		events     []*Event
+		incomplete bool
+	}{
+		{"empty", nil, false},
+		{"file", []*Event{{File: file}}, false},
+		{"archive member", []*Event{{File: member}}, false},
+		{"archive folder", []*Event{{Folder: &FolderEntry{FileEntry: FileEntry{Size: 10, Path: "/r/a.zip", Root: "/r", Archive: "/r/a.zip"}, Count: 1, Entries: 1}}}, false},
+		{"folders of several roots", []*Event{{Folder: folder}, {Folder: other}}, false},
+		{"mount", []*Event{{Mount: &MountEntry{Path: "/r/m", Device: 3}}}, false},
+		{"error", []*Event{{Error: &ErrorEntry{Path: "/r/b", Err: errors.New("denied")}}}, false},
+		{"file error", []*Event{{Error: &ErrorEntry{Path: "/r/f", Err: errors.New("denied"), File: true}}}, false},
+		{"incomplete", []*Event{{File: file}, {Folder: folder}}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewSnapshotWriter(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, evt := range test.events {
+				w.Write(evt)
+			}
+			if test.incomplete {
+				w.SetIncomplete()
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if w.Count() != int64(len(test.events)) {
+				t.Errorf("Count() = %d, want %d", w.Count(), len(test.events))
+			}
+
+			r, err := NewSnapshotReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var read []*Event
+			err = r.Scan(context.Background(), func(evt *Event) {
+				read = append(read, evt)
+			})
+
+			if test.incomplete {
+				if !errors.Is(err, ErrIncompleteSnapshot) || !r.Incomplete {
+					t.Errorf("incomplete snapshot expected but got %v", err)
+				}
+			} else if err != nil || r.Incomplete {
+				t.Errorf("complete snapshot expected but got %v", err)
+			}
+
+			if len(read) != len(test.events) {
+				t.Fatalf("%d events read, want %d", len(read), len(test.events))
+			}
+			for i, evt := range test.events {
+				assertSnapshotEvent(t, read[i], evt)
+			}
+		})
+	}
+}
+
+func assertSnapshotEvent(t *testing.T, got *Event, want *Event) {
+	t.Helper()
+
+	switch {
+	case want.File != nil:
+		if got.File == nil || !reflect.DeepEqual(*got.File, *want.File) {
+			t.Errorf("file %+v read, want %+v", got.File, want.File)
+		}
+	case want.Folder != nil:
+		if got.Folder == nil || !reflect.DeepEqual(*got.Folder, *want.Folder) {
+			t.Errorf("folder %+v read, want %+v", got.Folder, want.Folder)
+		}
+	case want.Mount != nil:
+		if got.Mount == nil || *got.Mount != *want.Mount {
+			t.Errorf("mount %+v read, want %+v", got.Mount, want.Mount)
+		}
+	case want.Error != nil:
+		if got.Error == nil || got.Error.Path != want.Error.Path || got.Error.Err.Error() != want.Error.Err.Error() || got.Error.File != want.Error.File {
+			t.Errorf("error %+v read, want %+v", got.Error, want.Error)
+		}
+	}
+}
+
+func TestSnapshotReader_Truncated(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewSnapshotWriter(&buf)
+	w.Write(&Event{Folder: &FolderEntry{FileEntry: FileEntry{Path: "/r", Root: "/r"}}})
+	// Flushed without the end record like after crash
+	_ = w.gz.Close()
+	_ = w.bw.Flush()
+
+	r, err := NewSnapshotReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.Scan(context.Background())
+	if !errors.Is(err, ErrIncompleteSnapshot) {
+		t.Errorf("ErrIncompleteSnapshot expected but got %v", err)
+	}
+}
+
+func TestNewSnapshotReader_NotSnapshot(t *testing.T) {
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"other file", "hello world\n"},
+		{"no version", snapshotMagic + " x\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewSnapshotReader(bytes.NewBufferString(test.data))
+			if !errors.Is(err, ErrNotSnapshot) {
+				t.Errorf("ErrNotSnapshot expected but got %v", err)
+			}
+		})
+	}
+}
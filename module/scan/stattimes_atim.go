@@ -0,0 +1,14 @@
+//go:build linux || openbsd || solaris || dragonfly
+// +build linux openbsd solaris dragonfly
+
+package scan
+
+import (
+	"syscall"
+	"time"
+)
+
+// statTimes gets file access and change times
+func statTimes(st *syscall.Stat_t) (time.Time, time.Time) {
+	return time.Unix(st.Atim.Unix()), time.Unix(st.Ctim.Unix())
+}
@@ -0,0 +1,14 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package scan
+
+import (
+	"syscall"
+	"time"
+)
+
+// statTimes gets file access and change times
+func statTimes(st *syscall.Stat_t) (time.Time, time.Time) {
+	return time.Unix(st.Atimespec.Unix()), time.Unix(st.Ctimespec.Unix())
+}
@@ -0,0 +1,15 @@
+//go:build !windows && !plan9 && !linux && !openbsd && !solaris && !dragonfly && !darwin && !freebsd && !netbsd
+// +build !windows,!plan9,!linux,!openbsd,!solaris,!dragonfly,!darwin,!freebsd,!netbsd
+
+package scan
+
+import (
+	"syscall"
+	"time"
+)
+
+// statTimes gets file access and change times.
+// They aren't available on this platform so zero times returned
+func statTimes(*syscall.Stat_t) (time.Time, time.Time) {
+	return time.Time{}, time.Time{}
+}
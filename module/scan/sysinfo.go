@@ -0,0 +1,60 @@
+package scan
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// fileID identifies file or folder by device and inode numbers
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// sysInfo contains platform specific file info
+type sysInfo struct {
+	id    fileID
+	nlink uint64
+
+	// allocated defines the number of bytes allocated on disk
+	allocated int64
+
+	uid uint32
+	gid uint32
+
+	// owner set if uid and gid are available
+	owner bool
+
+	atime time.Time
+	ctime time.Time
+}
+
+// idSet defines concurrent safe set of file identities
+type idSet struct {
+	mu  sync.Mutex
+	ids map[fileID]struct{}
+}
+
+func newIDSet() *idSet {
+	return &idSet{ids: make(map[fileID]struct{})}
+}
+
+// add adds id into set. It returns false if id was already there
+func (s *idSet) add(id fileID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+	s.ids[id] = struct{}{}
+	return true
+}
+
+// getFileID gets file or folder identity on a device.
+// It returns false if the file system doesn't provide it
+func getFileID(fi os.FileInfo) (fileID, bool) {
+	si, ok := getSysInfo(fi)
+	return si.id, ok
+}
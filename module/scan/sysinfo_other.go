@@ -0,0 +1,12 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package scan
+
+import "os"
+
+// getSysInfo gets platform specific file info.
+// Such info isn't available on this platform so it always returns false
+func getSysInfo(os.FileInfo) (sysInfo, bool) {
+	return sysInfo{}, false
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package scan
+
+import (
+	"os"
+	"syscall"
+)
+
+// getSysInfo gets platform specific file info.
+// It returns false if the file system doesn't provide it
+func getSysInfo(fi os.FileInfo) (sysInfo, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st == nil {
+		return sysInfo{}, false
+	}
+
+	si := sysInfo{
+		id:        fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)},
+		nlink:     uint64(st.Nlink),
+		allocated: allocated(st),
+		uid:       uint32(st.Uid),
+		gid:       uint32(st.Gid),
+		owner:     true,
+	}
+	si.atime, si.ctime = statTimes(st)
+	return si, true
+}
@@ -0,0 +1,43 @@
+package module
+
+import (
+	"github.com/aegoroff/dirstat/module/scan"
+)
+
+type snapshotWorker struct {
+	voidInit
+	voidFinalize
+	writer *scan.SnapshotWriter
+}
+
+type snapshotRenderer struct {
+	*snapshotWorker
+}
+
+func newSnapshotWorker(writer *scan.SnapshotWriter) *snapshotWorker {
+	return &snapshotWorker{writer: writer}
+}
+
+func newSnapshotRenderer(work *snapshotWorker) renderer {
+	return &snapshotRenderer{work}
+}
+
+// Worker methods
+
+func (m *snapshotWorker) handler(evt *scan.Event) {
+	m.writer.Write(evt)
+}
+
+// completed marks snapshot incomplete if scanning was interrupted or failed
+// so as the results shown from it are marked incomplete too
+func (m *snapshotWorker) completed(err error) {
+	if err != nil {
+		m.writer.SetIncomplete()
+	}
+}
+
+// Renderer method
+
+func (m *snapshotRenderer) print(p printer) {
+	p.cprint("\n<gray>Snapshot events written:</> %d\n", m.writer.Count())
+}
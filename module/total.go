@@ -20,7 +20,13 @@ func (m *totalRenderer) print(p printer) {
 	const totalTemplate = `
 Total files:            {{.FilesTotal.Count}} ({{.FilesTotal.Size | toBytesString }})
 Total folders:          {{.CountFolders}}
-Total file extensions:  {{.CountFileExts}}`
+Total file extensions:  {{.CountFileExts}}{{if .HardLinked.Count}}
+Hard-linked files:      {{.HardLinked.Count}} ({{.HardLinked.Size | toBytesString }} counted once){{end}}{{if .Archived.Count}}
+Files in archives:      {{.Archived.Count}} ({{.Archived.Size | toBytesString }} uncompressed, {{.Compressed | toBytesString }} compressed){{end}}{{if .Ignored.Count}}
+Not ignored files:      {{.NotIgnored.Count}} ({{.NotIgnored.Size | toBytesString }})
+Ignored files:          {{.Ignored.Count}} ({{.Ignored.Size | toBytesString }}){{end}}{{if .CountReadErrors}}
+Read errors:            {{.CountReadErrors}} directories could not be read{{end}}{{if .CountFileErrors}}
+File errors:            {{.CountFileErrors}} files could not be read{{end}}`
 
 	var report = template.Must(template.New("totalstat").Funcs(template.FuncMap{"toBytesString": humanize.IBytes}).Parse(totalTemplate))
 
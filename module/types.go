@@ -2,8 +2,10 @@ package module
 
 import (
 	"fmt"
+	"github.com/aegoroff/dirstat/module/scan"
 	"github.com/aegoroff/godatastruct/rbtree"
 	"github.com/dustin/go-humanize"
+	"strings"
 	"time"
 )
 
@@ -16,6 +18,17 @@ const (
 	pbyte
 )
 
+// Usage defines which file size is used to calculate and rank statistic
+type Usage int
+
+const (
+	// UsageApparent defines file size i.e. the number of bytes in it
+	UsageApparent Usage = iota
+
+	// UsageDisk defines the number of bytes allocated on disk for the file
+	UsageDisk
+)
+
 // Range defined integer value range
 type Range struct {
 	// Min value
@@ -38,10 +51,20 @@ type fileStat struct {
 }
 
 type totalInfo struct {
-	ReadingTime   time.Duration
-	FilesTotal    countSizeAggregate
-	CountFolders  int64
-	CountFileExts int
+	ReadingTime     time.Duration
+	FilesTotal      countSizeAggregate
+	CountFolders    int64
+	CountFileExts   int
+	CountReadErrors int64
+	CountFileErrors int64
+	HardLinked      countSizeAggregate
+	Ignored         countSizeAggregate
+	Archived        countSizeAggregate
+	Compressed      uint64
+
+	// Roots contains scanned paths in the order they were scanned
+	Roots     []string
+	rootIndex map[string]int
 }
 
 type countSizeAggregate struct {
@@ -54,6 +77,31 @@ type fixedTree struct {
 	size int
 }
 
+// size gets file size according to usage
+func (u Usage) size(f *scan.FileEntry) int64 {
+	if u == UsageDisk {
+		return f.Allocated
+	}
+	return f.Size
+}
+
+// counted gets the number of bytes the file adds to totals.
+// A hard link to an inode that was already counted adds nothing
+func (u Usage) counted(f *scan.FileEntry) uint64 {
+	if f.Duplicate {
+		return 0
+	}
+	return uint64(u.size(f))
+}
+
+// NotIgnored gets files statistic excluding ignored ones
+func (t *totalInfo) NotIgnored() countSizeAggregate {
+	return countSizeAggregate{
+		Count: t.FilesTotal.Count - t.Ignored.Count,
+		Size:  t.FilesTotal.Size - t.Ignored.Size,
+	}
+}
+
 func (t *totalInfo) countPercent(count int64) float64 {
 	return (float64(count) / float64(t.FilesTotal.Count)) * 100
 }
@@ -62,11 +110,31 @@ func (t *totalInfo) sizePercent(size uint64) float64 {
 	return (float64(size) / float64(t.FilesTotal.Size)) * 100
 }
 
-func (t *totalInfo) printCountAndSizeStatLine(p printer, count int64, sz uint64, title string) {
+// printCountAndSizeStatLine prints table row with count and size and their percents.
+// extra cells (for example per scanned path breakdown) are added to the end of the row
+func (t *totalInfo) printCountAndSizeStatLine(p printer, count int64, sz uint64, title string, extra ...string) {
 	percentOfCount := t.countPercent(count)
 	percentOfSize := t.sizePercent(sz)
 
-	p.print("%v\t%v\t%.2f%%\t%v\t%.2f%%\n", title, count, percentOfCount, humanize.IBytes(sz), percentOfSize)
+	p.print("%v\t%v\t%.2f%%\t%v\t%.2f%%", title, count, percentOfCount, humanize.IBytes(sz), percentOfSize)
+	for _, e := range extra {
+		p.print("\t%v", e)
+	}
+	p.print("\n")
+}
+
+// printTableHead prints table heads row and heads underline row
+func printTableHead(p printer, heads ...string) {
+	lines := make([]string, len(heads))
+	for i, h := range heads {
+		if h == "%" {
+			lines[i] = "------"
+		} else {
+			lines[i] = strings.Repeat("-", len(h))
+		}
+	}
+	p.print("%s\n", strings.Join(heads, "\t"))
+	p.print("%s\n", strings.Join(lines, "\t"))
 }
 
 func newFixedTree(sz int) *fixedTree {